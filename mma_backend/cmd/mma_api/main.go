@@ -7,7 +7,16 @@ import (
 	"log/slog"
 	"mma_api/internal/config"
 	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/http/handlers/inventory"
+	"mma_api/internal/http/handlers/maintenance"
+	"mma_api/internal/http/handlers/order"
 	"mma_api/internal/http/handlers/product"
+	"mma_api/internal/http/handlers/scrap"
+	"mma_api/internal/http/handlers/skill"
+	"mma_api/internal/http/handlers/timesheet"
+	"mma_api/internal/http/handlers/workcenter"
+	"mma_api/internal/http/handlers/workorder"
+	"mma_api/internal/manufacturing"
 	"mma_api/internal/storage/postgres"
 	"net/http"
 	"os"
@@ -19,6 +28,7 @@ import (
 func main() {
 	//config
 	cfg := *config.Must_Load()
+	auth.SetTokenKey(cfg.Token_Secret)
 	pg, err := postgres.New(&cfg)
 	if err != nil {
 		fmt.Print("yo the postgres is not working", err)
@@ -37,11 +47,121 @@ func main() {
 	router.HandleFunc("GET /api/users/{id}", auth.GetUserByIDHandler(pg))
 	router.HandleFunc("DELETE /api/users/{id}", auth.DeleteUserByIDHandler(pg))
 	router.HandleFunc("PUT /api/users/{id}", auth.UpdateUserHandler(pg))
+	router.HandleFunc("GET /api/users/{id}/qualifications", auth.GetQualificationsHandler(pg))
+	router.HandleFunc("POST /api/users/{id}/qualifications", auth.AddQualificationHandler(pg))
+	router.HandleFunc("DELETE /api/users/{id}/qualifications/{wcId}", auth.DeleteQualificationHandler(pg))
+	router.HandleFunc("GET /api/users/{id}/skills", skill.GetUserSkillsHandler(pg))
+	router.HandleFunc("POST /api/users/{id}/skills", skill.SetUserSkillHandler(pg))
+	router.HandleFunc("DELETE /api/users/{id}/skills/{skillId}", skill.DeleteUserSkillHandler(pg))
+	router.HandleFunc("POST /api/skills", skill.CreateSkillHandler(pg))
+	router.HandleFunc("GET /api/skills", skill.GetSkillsHandler(pg))
+	router.HandleFunc("DELETE /api/skills/{id}", skill.DeleteSkillHandler(pg))
+	router.HandleFunc("GET /api/reports/certifications/expiring", skill.ExpiringHandler(pg))
 	router.HandleFunc("GET /api/products/", product.GetProductsHandler(pg))
 	router.HandleFunc("GET /api/products/{id}", product.GetProductByIDHandler(pg))
 	router.HandleFunc("POST /api/products/", product.CreateProductHandler(pg))
+	router.HandleFunc("PUT /api/products/{id}", product.UpdateProductHandler(pg))
 	router.HandleFunc("POST /api/products/{id}/bom", product.CreateBoMHandler(pg))
 	router.HandleFunc("GET /api/products/{id}/bom", product.GetBoMHandler(pg))
+	router.HandleFunc("PUT /api/products/{id}/bom/yield", product.SetBoMYieldHandler(pg))
+	router.HandleFunc("GET /api/products/{id}/bom/explode", product.ExplodeBoMHandler(pg))
+	router.HandleFunc("POST /api/products/{id}/bom/{bomId}/alternates", product.CreateBoMAlternateHandler(pg))
+	router.HandleFunc("GET /api/products/{id}/bom/{bomId}/alternates", product.GetBoMAlternatesHandler(pg))
+	router.HandleFunc("DELETE /api/products/{id}/bom/{bomId}/alternates/{altId}", product.DeleteBoMAlternateHandler(pg))
+	router.HandleFunc("GET /api/products/{id}/availability", product.GetAvailabilityHandler(pg))
+	router.HandleFunc("GET /api/boms/diff", product.DiffBoMHandler(pg))
+	router.HandleFunc("POST /api/products/{id}/routing", product.CreateRoutingOperationHandler(pg))
+	router.HandleFunc("GET /api/products/{id}/routing", product.GetRoutingHandler(pg))
+	router.HandleFunc("PUT /api/products/{id}/routing/{opId}", product.UpdateRoutingOperationHandler(pg))
+	router.HandleFunc("DELETE /api/products/{id}/routing/{opId}", product.DeleteRoutingOperationHandler(pg))
+	router.HandleFunc("GET /api/products/{id}/routing/{opId}/skills", skill.GetOperationSkillsHandler(pg))
+	router.HandleFunc("PUT /api/products/{id}/routing/{opId}/skills", skill.SetOperationSkillsHandler(pg))
+	router.HandleFunc("GET /api/products/{id}/cost", product.GetProductCostHandler(pg))
+	router.HandleFunc("POST /api/products/{id}/cost", product.RollProductCostHandler(pg))
+	router.HandleFunc("GET /api/products/{id}/cost/history", product.GetProductCostHistoryHandler(pg))
+	router.HandleFunc("POST /api/manufacturing-orders", order.CreateOrderHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders", order.GetOrdersHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/board", order.GetBoardHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}", order.GetOrderByIDHandler(pg))
+	router.HandleFunc("PUT /api/manufacturing-orders/{id}", order.UpdateOrderHandler(pg))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/confirm", order.TransitionHandler(pg, manufacturing.ActionConfirm))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/start", order.TransitionHandler(pg, manufacturing.ActionStart))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/complete", order.CompleteHandler(pg))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/hold", order.TransitionHandler(pg, manufacturing.ActionHold))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/resume", order.TransitionHandler(pg, manufacturing.ActionResume))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/cancel", order.TransitionHandler(pg, manufacturing.ActionCancel))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}/transitions", order.GetTransitionsHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}/work-orders", order.GetOrderWorkOrdersHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}/availability", order.GetOrderAvailabilityHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}/reservations", order.GetReservationsHandler(pg))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/reservations", order.ReserveMaterialsHandler(pg))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/consume", order.ConsumeMaterialHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}/movements", order.GetMovementsHandler(pg))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/produce", order.ProduceHandler(pg))
+	router.HandleFunc("POST /api/manufacturing-orders/{id}/split", order.SplitHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}/children", order.GetChildrenHandler(pg))
+	router.HandleFunc("PUT /api/manufacturing-orders/{id}/priority", order.SetPriorityHandler(pg))
+	router.HandleFunc("GET /api/manufacturing-orders/{id}/dependencies", order.GetDependenciesHandler(pg))
+	router.HandleFunc("POST /api/inventory/movements", inventory.PostMovementHandler(pg))
+	router.HandleFunc("GET /api/inventory/lots", inventory.GetLotsHandler(pg))
+	router.HandleFunc("GET /api/inventory/trace/backward", inventory.TraceHandler(pg, false))
+	router.HandleFunc("GET /api/inventory/trace/forward", inventory.TraceHandler(pg, true))
+	router.HandleFunc("GET /api/me/work-orders", workorder.GetMyWorkOrdersHandler(pg))
+	router.HandleFunc("GET /api/work-orders/{id}", workorder.GetWorkOrderHandler(pg))
+	router.HandleFunc("POST /api/work-orders/{id}/start", workorder.ActionHandler(pg, manufacturing.WOActionStart))
+	router.HandleFunc("POST /api/work-orders/{id}/pause", workorder.ActionHandler(pg, manufacturing.WOActionPause))
+	router.HandleFunc("POST /api/work-orders/{id}/resume", workorder.ActionHandler(pg, manufacturing.WOActionResume))
+	router.HandleFunc("POST /api/work-orders/{id}/complete", workorder.ActionHandler(pg, manufacturing.WOActionComplete))
+	router.HandleFunc("GET /api/work-orders/{id}/time-logs", workorder.GetTimeLogsHandler(pg))
+	router.HandleFunc("POST /api/work-orders/{id}/clock-in", workorder.ClockHandler(pg, true))
+	router.HandleFunc("POST /api/work-orders/{id}/clock-out", workorder.ClockHandler(pg, false))
+	router.HandleFunc("GET /api/work-orders/{id}/labour", workorder.GetLabourHandler(pg))
+	router.HandleFunc("GET /api/me/timesheet", timesheet.Handler(pg, true))
+	router.HandleFunc("GET /api/users/{id}/timesheet", timesheet.Handler(pg, false))
+	router.HandleFunc("POST /api/work-orders/{id}/dependencies", workorder.AddDependencyHandler(pg))
+	router.HandleFunc("DELETE /api/work-orders/{id}/dependencies/{predId}", workorder.DeleteDependencyHandler(pg))
+	router.HandleFunc("POST /api/work-orders/{id}/reorder", workorder.ReorderHandler(pg))
+	router.HandleFunc("PUT /api/work-orders/{id}/assign", workorder.AssignHandler(pg))
+	router.HandleFunc("POST /api/work-orders/{id}/scrap", workorder.ReportScrapHandler(pg))
+	router.HandleFunc("GET /api/work-orders/{id}/scrap", workorder.GetScrapHandler(pg))
+	router.HandleFunc("POST /api/scrap-reasons", scrap.CreateReasonHandler(pg))
+	router.HandleFunc("GET /api/scrap-reasons", scrap.GetReasonsHandler(pg))
+	router.HandleFunc("PUT /api/scrap-reasons/{id}", scrap.UpdateReasonHandler(pg))
+	router.HandleFunc("GET /api/reports/scrap", scrap.ReportHandler(pg))
+	router.HandleFunc("POST /api/work-centers", workcenter.CreateWorkCenterHandler(pg))
+	router.HandleFunc("GET /api/work-centers", workcenter.GetWorkCentersHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}", workcenter.GetWorkCenterByIDHandler(pg))
+	router.HandleFunc("PUT /api/work-centers/{id}", workcenter.UpdateWorkCenterHandler(pg))
+	router.HandleFunc("DELETE /api/work-centers/{id}", workcenter.DeleteWorkCenterHandler(pg))
+	router.HandleFunc("PUT /api/work-centers/{id}/status", workcenter.SetStatusHandler(pg))
+	router.HandleFunc("PUT /api/work-centers/{id}/rates", workcenter.SetRatesHandler(pg))
+	router.HandleFunc("PUT /api/work-centers/{id}/shift-pattern", workcenter.SetShiftPatternHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}/capacity", workcenter.GetCapacityHandler(pg))
+	router.HandleFunc("POST /api/work-centers/{id}/downtime", workcenter.CreateDowntimeHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}/downtime", workcenter.GetDowntimeHandler(pg))
+	router.HandleFunc("POST /api/work-centers/{id}/downtime/{eventId}/end", workcenter.EndDowntimeHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}/oee", workcenter.GetOEEHandler(pg))
+	router.HandleFunc("POST /api/work-centers/{id}/maintenance-plans", maintenance.CreatePlanHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}/maintenance-plans", maintenance.GetPlansHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}/maintenance/history", maintenance.GetHistoryHandler(pg))
+	router.HandleFunc("PUT /api/maintenance-plans/{id}", maintenance.UpdatePlanHandler(pg))
+	router.HandleFunc("DELETE /api/maintenance-plans/{id}", maintenance.DeletePlanHandler(pg))
+	router.HandleFunc("GET /api/maintenance/tasks", maintenance.GetTasksHandler(pg))
+	router.HandleFunc("POST /api/maintenance/tasks/generate", maintenance.GenerateTasksHandler(pg))
+	router.HandleFunc("PUT /api/maintenance/tasks/{id}", maintenance.RescheduleTaskHandler(pg))
+	router.HandleFunc("POST /api/maintenance/tasks/{id}/start", maintenance.StartTaskHandler(pg))
+	router.HandleFunc("POST /api/maintenance/tasks/{id}/complete", maintenance.CompleteTaskHandler(pg))
+	router.HandleFunc("POST /api/shift-patterns", workcenter.CreateShiftPatternHandler(pg))
+	router.HandleFunc("GET /api/shift-patterns", workcenter.GetShiftPatternsHandler(pg))
+	router.HandleFunc("GET /api/shift-patterns/{id}", workcenter.GetShiftPatternByIDHandler(pg))
+	router.HandleFunc("PUT /api/shift-patterns/{id}", workcenter.UpdateShiftPatternHandler(pg))
+	router.HandleFunc("DELETE /api/shift-patterns/{id}", workcenter.DeleteShiftPatternHandler(pg))
+	router.HandleFunc("POST /api/holidays", workcenter.CreateHolidayHandler(pg))
+	router.HandleFunc("GET /api/holidays", workcenter.GetHolidaysHandler(pg))
+	router.HandleFunc("DELETE /api/holidays/{id}", workcenter.DeleteHolidayHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}/queue", workcenter.GetQueueHandler(pg))
+	router.HandleFunc("GET /api/work-centers/{id}/skills", skill.GetWorkCenterSkillsHandler(pg))
+	router.HandleFunc("PUT /api/work-centers/{id}/skills", skill.SetWorkCenterSkillsHandler(pg))
 
 	//setup server
 	server := http.Server{
@@ -0,0 +1,35 @@
+// Package apperr sorts errors into the few kinds that callers answer
+// differently, so storage and the domain packages can say what went wrong
+// without knowing how it is reported.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The kinds of error. Test for one with errors.Is.
+var (
+	NotFound  = errors.New("not found")
+	Conflict  = errors.New("conflict")
+	Forbidden = errors.New("forbidden")
+	Invalid   = errors.New("invalid")
+)
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// New returns an error of the given kind that reads msg.
+func New(kind error, msg string) error {
+	return &kindError{kind: kind, err: errors.New(msg)}
+}
+
+// Newf is New with a message formatted as by fmt.Errorf, %w included.
+func Newf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
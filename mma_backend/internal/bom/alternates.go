@@ -0,0 +1,65 @@
+package bom
+
+import "sort"
+
+// Candidate is a component that can cover a BoM line: the primary itself
+// (AlternateID 0, ratio 1) or one of its approved substitutes.
+type Candidate struct {
+	AlternateID int `json:"alternate_id,omitempty"`
+	ComponentID int `json:"component_id"`
+	Priority    int `json:"priority"`
+	// ConversionRatio is how many units of this component replace one unit of the primary.
+	ConversionRatio float64 `json:"conversion_ratio"`
+	Available       float64 `json:"available"`
+}
+
+// Allocation is the part of a requirement covered by one candidate, in that
+// candidate's own units.
+type Allocation struct {
+	ComponentID int     `json:"component_id"`
+	AlternateID int     `json:"alternate_id,omitempty"`
+	Substitute  bool    `json:"substitute"`
+	Quantity    float64 `json:"quantity"`
+}
+
+// Allocate covers `required` units of the primary component, exhausting the
+// primary first and then the alternates in priority order (lowest number
+// first). It returns what was taken from each candidate and the part of the
+// requirement, in primary units, that nothing could cover.
+func Allocate(required float64, primary Candidate, alternates []Candidate) ([]Allocation, float64) {
+	primary.ConversionRatio = 1
+	ordered := append([]Candidate{primary}, alternates...)
+	sort.SliceStable(ordered[1:], func(i, j int) bool {
+		return ordered[1+i].Priority < ordered[1+j].Priority
+	})
+
+	var allocs []Allocation
+	remaining := required
+	for _, c := range ordered {
+		if remaining <= 1e-9 {
+			break
+		}
+		ratio := c.ConversionRatio
+		if ratio <= 0 {
+			ratio = 1
+		}
+		if c.Available <= 0 {
+			continue
+		}
+		take := remaining * ratio
+		if take > c.Available {
+			take = c.Available
+		}
+		allocs = append(allocs, Allocation{
+			ComponentID: c.ComponentID,
+			AlternateID: c.AlternateID,
+			Substitute:  c.AlternateID != 0,
+			Quantity:    take,
+		})
+		remaining -= take / ratio
+	}
+	if remaining < 1e-9 {
+		remaining = 0
+	}
+	return allocs, remaining
+}
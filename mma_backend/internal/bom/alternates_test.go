@@ -0,0 +1,92 @@
+package bom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	m6 := Candidate{ComponentID: 1, Available: 10}
+	tests := []struct {
+		name         string
+		required     float64
+		primary      Candidate
+		alternates   []Candidate
+		want         []Allocation
+		wantShortage float64
+	}{
+		{
+			name:     "primary covers it",
+			required: 6, primary: m6,
+			alternates: []Candidate{{AlternateID: 7, ComponentID: 2, Priority: 1, ConversionRatio: 1, Available: 50}},
+			want:       []Allocation{{ComponentID: 1, Quantity: 6}},
+		},
+		{
+			name:     "substitutes in priority order",
+			required: 25, primary: m6,
+			alternates: []Candidate{
+				{AlternateID: 8, ComponentID: 3, Priority: 2, ConversionRatio: 1, Available: 50},
+				{AlternateID: 7, ComponentID: 2, Priority: 1, ConversionRatio: 1, Available: 5},
+			},
+			want: []Allocation{
+				{ComponentID: 1, Quantity: 10},
+				{ComponentID: 2, AlternateID: 7, Substitute: true, Quantity: 5},
+				{ComponentID: 3, AlternateID: 8, Substitute: true, Quantity: 10},
+			},
+		},
+		{
+			name:     "conversion ratio in the substitute's units",
+			required: 14, primary: m6,
+			alternates: []Candidate{{AlternateID: 7, ComponentID: 2, Priority: 1, ConversionRatio: 0.5, Available: 10}},
+			want: []Allocation{
+				{ComponentID: 1, Quantity: 10},
+				{ComponentID: 2, AlternateID: 7, Substitute: true, Quantity: 2},
+			},
+		},
+		{
+			name:     "missing ratio counts as one",
+			required: 12, primary: m6,
+			alternates: []Candidate{{AlternateID: 7, ComponentID: 2, Priority: 1, Available: 10}},
+			want: []Allocation{
+				{ComponentID: 1, Quantity: 10},
+				{ComponentID: 2, AlternateID: 7, Substitute: true, Quantity: 2},
+			},
+		},
+		{
+			name:     "shortage in primary units",
+			required: 20, primary: m6,
+			alternates: []Candidate{
+				{AlternateID: 7, ComponentID: 2, Priority: 1, ConversionRatio: 2, Available: 4},
+				{AlternateID: 8, ComponentID: 3, Priority: 2, ConversionRatio: 1},
+			},
+			want: []Allocation{
+				{ComponentID: 1, Quantity: 10},
+				{ComponentID: 2, AlternateID: 7, Substitute: true, Quantity: 4},
+			},
+			wantShortage: 8,
+		},
+		{
+			name:     "nothing in stock",
+			required: 3, primary: Candidate{ComponentID: 1},
+			wantShortage: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, shortage := Allocate(tt.required, tt.primary, tt.alternates)
+			if math.Abs(shortage-tt.wantShortage) > 1e-9 {
+				t.Errorf("shortage = %v, want %v", shortage, tt.wantShortage)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("allocations = %+v, want %+v", got, tt.want)
+			}
+			for i, a := range got {
+				w := tt.want[i]
+				if a.ComponentID != w.ComponentID || a.AlternateID != w.AlternateID || a.Substitute != w.Substitute ||
+					math.Abs(a.Quantity-w.Quantity) > 1e-9 {
+					t.Errorf("allocation %d = %+v, want %+v", i, a, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package bom
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeQuantity = "quantity_changed"
+)
+
+type Change struct {
+	Type             string   `json:"type"`
+	Level            int      `json:"level"`
+	Path             []int    `json:"path"`
+	PathNames        []string `json:"path_names"`
+	ComponentID      int      `json:"component_id"`
+	ComponentName    string   `json:"component_name"`
+	OldQuantity      float64  `json:"old_quantity"`
+	NewQuantity      float64  `json:"new_quantity"`
+	OldTotalQuantity float64  `json:"old_total_quantity"`
+	NewTotalQuantity float64  `json:"new_total_quantity"`
+}
+
+type Ref struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Version     int    `json:"version"`
+}
+
+type Diff struct {
+	From    Ref      `json:"from"`
+	To      Ref      `json:"to"`
+	Added   []Change `json:"added"`
+	Removed []Change `json:"removed"`
+	Changed []Change `json:"changed"`
+}
+
+// flatEntry is one position in an exploded tree, keyed by the chain of
+// component ids from the root so the same part under two parents stays apart.
+type flatEntry struct {
+	path          []int
+	names         []string
+	quantity      float64
+	totalQuantity float64
+}
+
+func flatten(nodes []Node, path []int, names []string, out map[string]*flatEntry) {
+	for _, n := range nodes {
+		p := append(append([]int{}, path...), n.ComponentID)
+		nm := append(append([]string{}, names...), n.ComponentName)
+		key := pathKey(p)
+		// two lines for the same component under one parent are compared as one
+		if e, ok := out[key]; ok {
+			e.quantity += n.Quantity
+			e.totalQuantity += n.TotalQuantity
+		} else {
+			out[key] = &flatEntry{path: p, names: nm, quantity: n.Quantity, totalQuantity: n.TotalQuantity}
+		}
+		flatten(n.Children, p, nm, out)
+	}
+}
+
+func pathKey(path []int) string {
+	parts := make([]string, len(path))
+	for i, id := range path {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Compare reports every component that was added, removed or changed quantity
+// between two exploded trees, at every level. A line counts as changed when its
+// own quantity per parent differs, not when a parent's change merely scales
+// its total.
+func Compare(from, to *Tree) *Diff {
+	a := map[string]*flatEntry{}
+	b := map[string]*flatEntry{}
+	flatten(from.Lines, nil, nil, a)
+	flatten(to.Lines, nil, nil, b)
+
+	d := &Diff{
+		From:    Ref{ProductID: from.ProductID, ProductName: from.ProductName, Version: from.Version},
+		To:      Ref{ProductID: to.ProductID, ProductName: to.ProductName, Version: to.Version},
+		Added:   []Change{},
+		Removed: []Change{},
+		Changed: []Change{},
+	}
+
+	for key, old := range a {
+		cur, ok := b[key]
+		if !ok {
+			d.Removed = append(d.Removed, change(ChangeRemoved, old, old.quantity, 0, old.totalQuantity, 0))
+			continue
+		}
+		// totals follow every parent above; only the line's own quantity
+		// tells whether it was edited at this level
+		if !sameQuantity(old.quantity, cur.quantity) {
+			d.Changed = append(d.Changed, change(ChangeQuantity, cur, old.quantity, cur.quantity, old.totalQuantity, cur.totalQuantity))
+		}
+	}
+	for key, cur := range b {
+		if _, ok := a[key]; !ok {
+			d.Added = append(d.Added, change(ChangeAdded, cur, 0, cur.quantity, 0, cur.totalQuantity))
+		}
+	}
+
+	for _, list := range [][]Change{d.Added, d.Removed, d.Changed} {
+		sort.Slice(list, func(i, j int) bool {
+			return pathKey(list[i].Path) < pathKey(list[j].Path)
+		})
+	}
+	return d
+}
+
+func change(kind string, e *flatEntry, oldQty, newQty, oldTotal, newTotal float64) Change {
+	last := len(e.path) - 1
+	return Change{
+		Type:             kind,
+		Level:            len(e.path),
+		Path:             e.path,
+		PathNames:        e.names,
+		ComponentID:      e.path[last],
+		ComponentName:    e.names[last],
+		OldQuantity:      oldQty,
+		NewQuantity:      newQty,
+		OldTotalQuantity: oldTotal,
+		NewTotalQuantity: newTotal,
+	}
+}
+
+func sameQuantity(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func (r Ref) String() string {
+	return fmt.Sprintf("%s (#%d) v%d", r.ProductName, r.ProductID, r.Version)
+}
+
+// Text renders the diff for terminals and change-review emails.
+func (d *Diff) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "BoM diff: %s -> %s\n", d.From, d.To)
+	if d.Empty() {
+		sb.WriteString("no differences\n")
+		return sb.String()
+	}
+	for _, c := range d.all() {
+		indent := strings.Repeat("  ", c.Level-1)
+		switch c.Type {
+		case ChangeAdded:
+			fmt.Fprintf(&sb, "+ %s%s  qty %g (total %g)\n", indent, c.ComponentName, c.NewQuantity, c.NewTotalQuantity)
+		case ChangeRemoved:
+			fmt.Fprintf(&sb, "- %s%s  qty %g (total %g)\n", indent, c.ComponentName, c.OldQuantity, c.OldTotalQuantity)
+		default:
+			fmt.Fprintf(&sb, "~ %s%s  qty %g -> %g (total %g -> %g)\n", indent, c.ComponentName,
+				c.OldQuantity, c.NewQuantity, c.OldTotalQuantity, c.NewTotalQuantity)
+		}
+	}
+	return sb.String()
+}
+
+// Markdown renders the diff as a table that can be pasted into a review.
+func (d *Diff) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### BoM diff: %s → %s\n\n", d.From, d.To)
+	if d.Empty() {
+		sb.WriteString("_No differences._\n")
+		return sb.String()
+	}
+	sb.WriteString("| Change | Level | Path | Old qty | New qty | Old total | New total |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, c := range d.all() {
+		fmt.Fprintf(&sb, "| %s | %d | %s | %g | %g | %g | %g |\n", c.Type, c.Level,
+			strings.Join(c.PathNames, " › "), c.OldQuantity, c.NewQuantity, c.OldTotalQuantity, c.NewTotalQuantity)
+	}
+	return sb.String()
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// all merges the three lists in tree order so parents print before children.
+func (d *Diff) all() []Change {
+	list := append(append(append([]Change{}, d.Removed...), d.Added...), d.Changed...)
+	sort.SliceStable(list, func(i, j int) bool {
+		return pathKey(list[i].Path) < pathKey(list[j].Path)
+	})
+	return list
+}
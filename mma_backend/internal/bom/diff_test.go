@@ -0,0 +1,109 @@
+package bom
+
+import (
+	"strings"
+	"testing"
+)
+
+// wheels builds a bicycle tree of two levels: wheels with spokes laced into
+// each, and a frame.
+func wheels(version int, perBicycle, spokes float64) *Tree {
+	return &Tree{ProductID: 1, ProductName: "bicycle", Version: version, Lines: []Node{
+		{ComponentID: 2, ComponentName: "wheel", Quantity: perBicycle, TotalQuantity: perBicycle, Level: 1, Children: []Node{
+			{ComponentID: 4, ComponentName: "spoke", Quantity: spokes, TotalQuantity: perBicycle * spokes, Level: 2},
+		}},
+		{ComponentID: 3, ComponentName: "frame", Quantity: 1, TotalQuantity: 1, Level: 1},
+	}}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	d := Compare(wheels(1, 2, 32), wheels(2, 2, 32))
+	if !d.Empty() {
+		t.Errorf("equal trees differ: %+v", d)
+	}
+	if d.From.Version != 1 || d.To.Version != 2 {
+		t.Errorf("compared v%d with v%d, want v1 with v2", d.From.Version, d.To.Version)
+	}
+}
+
+func TestCompareChangedChild(t *testing.T) {
+	d := Compare(wheels(1, 2, 32), wheels(2, 2, 36))
+	if len(d.Added) != 0 || len(d.Removed) != 0 || len(d.Changed) != 1 {
+		t.Fatalf("diff = %+v, want one change", d)
+	}
+	c := d.Changed[0]
+	if got := strings.Join(c.PathNames, "/"); got != "wheel/spoke" || c.Level != 2 {
+		t.Errorf("changed %s at level %d, want wheel/spoke at level 2", got, c.Level)
+	}
+	if c.OldQuantity != 32 || c.NewQuantity != 36 || c.OldTotalQuantity != 64 || c.NewTotalQuantity != 72 {
+		t.Errorf("quantities %v -> %v, totals %v -> %v; want 32 -> 36 and 64 -> 72",
+			c.OldQuantity, c.NewQuantity, c.OldTotalQuantity, c.NewTotalQuantity)
+	}
+}
+
+func TestCompareScaledByParent(t *testing.T) {
+	// Three wheels take 96 spokes instead of 64, but the spoke line itself
+	// was not edited.
+	d := Compare(wheels(1, 2, 32), wheels(2, 3, 32))
+	if len(d.Changed) != 1 || d.Changed[0].ComponentName != "wheel" {
+		t.Errorf("changed = %+v, want only the wheel", d.Changed)
+	}
+}
+
+func TestCompareAddedAndRemoved(t *testing.T) {
+	to := wheels(2, 2, 32)
+	to.Lines[1] = Node{ComponentID: 5, ComponentName: "bell", Quantity: 1, TotalQuantity: 1, Level: 1}
+
+	d := Compare(wheels(1, 2, 32), to)
+	if len(d.Added) != 1 || d.Added[0].ComponentName != "bell" || d.Added[0].NewQuantity != 1 {
+		t.Errorf("added = %+v, want the bell", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].ComponentName != "frame" || d.Removed[0].OldQuantity != 1 {
+		t.Errorf("removed = %+v, want the frame", d.Removed)
+	}
+	if len(d.Changed) != 0 {
+		t.Errorf("changed = %+v, want nothing", d.Changed)
+	}
+}
+
+func TestCompareSumsRepeatedLines(t *testing.T) {
+	from := wheels(1, 2, 32)
+	from.Lines = append(from.Lines, from.Lines[1])
+	to := wheels(2, 2, 32)
+	to.Lines[1].Quantity, to.Lines[1].TotalQuantity = 2, 2
+
+	if d := Compare(from, to); !d.Empty() {
+		t.Errorf("two frame lines against one line of two differ: %+v", d)
+	}
+}
+
+func TestCompareSamePartUnderNewParent(t *testing.T) {
+	to := wheels(2, 2, 32)
+	to.Lines = append(to.Lines, Node{ComponentID: 6, ComponentName: "hub", Quantity: 2, TotalQuantity: 2, Level: 1,
+		Children: []Node{{ComponentID: 4, ComponentName: "spoke", Quantity: 1, TotalQuantity: 2, Level: 2}}})
+
+	d := Compare(wheels(1, 2, 32), to)
+	var added []string
+	for _, c := range d.Added {
+		added = append(added, strings.Join(c.PathNames, "/"))
+	}
+	if got := strings.Join(added, ", "); got != "hub, hub/spoke" {
+		t.Errorf("added %q, want the hub and its spokes", got)
+	}
+	if len(d.Changed) != 0 {
+		t.Errorf("the wheel spokes changed: %+v", d.Changed)
+	}
+}
+
+func TestDiffText(t *testing.T) {
+	got := Compare(wheels(1, 2, 32), wheels(2, 2, 36)).Text()
+	want := "BoM diff: bicycle (#1) v1 -> bicycle (#1) v2\n" +
+		"~   spoke  qty 32 -> 36 (total 64 -> 72)\n"
+	if got != want {
+		t.Errorf("Text() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Compare(wheels(1, 2, 32), wheels(1, 2, 32)).Text(); !strings.HasSuffix(got, "no differences\n") {
+		t.Errorf("Text() of an empty diff = %q", got)
+	}
+}
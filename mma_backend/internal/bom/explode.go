@@ -0,0 +1,195 @@
+package bom
+
+import (
+	"fmt"
+	"mma_api/internal/apperr"
+	"mma_api/internal/types"
+)
+
+var ErrCycle = apperr.New(apperr.Invalid, "bom contains a cycle")
+
+// ErrInvalidLine is returned for a BoM line that does not fit the rest of its
+// product: an operation outside its routing, or one defined twice with
+// different work centers or times.
+var ErrInvalidLine = apperr.New(apperr.Invalid, "invalid bom line")
+
+// Source is what the explosion needs from storage.
+type Source interface {
+	GetBoMVersion(productID, version int) ([]types.BoM, error)
+	GetBoMYield(productID, version int) (float64, error)
+	GetProductById(id int) (*types.Product, error)
+}
+
+type Node struct {
+	BoMID         int     `json:"bom_id"`
+	ComponentID   int     `json:"component_id"`
+	ComponentName string  `json:"component_name"`
+	Quantity      float64 `json:"quantity"`
+	ScrapPercent  float64 `json:"scrap_percent"`
+	Phantom       bool    `json:"phantom"`
+	TotalQuantity float64 `json:"total_quantity"`
+	OperationName string  `json:"operation_name,omitempty"`
+	OperationID   *int    `json:"operation_id,omitempty"`
+	Level         int     `json:"level"`
+	Children      []Node  `json:"children,omitempty"`
+}
+
+type Tree struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Version     int    `json:"version"`
+	// YieldPercent is the share of good output of the root BoM; it is
+	// already folded into the total quantities below.
+	YieldPercent float64 `json:"yield_percent"`
+	Lines        []Node  `json:"lines"`
+}
+
+// Explode walks the BoM of productID down to purchased components. The root
+// uses the requested version (0 = latest), sub-assemblies always use their latest.
+// Asking for a version that has no lines fails with not found.
+// Total quantities are gross requirements per unit of the root: each line is
+// inflated by its scrap percentage and divided by the output yield of the BoM
+// it belongs to.
+func Explode(src Source, productID, version int) (*Tree, error) {
+	e := explosion{src: src, names: map[int]string{}}
+
+	name, err := e.name(productID)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := src.GetBoMVersion(productID, version)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && len(lines) == 0 {
+		return nil, apperr.Newf(apperr.NotFound, "BoM version %d of product %d not found", version, productID)
+	}
+
+	yield, err := src.GetBoMYield(productID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{ProductID: productID, ProductName: name, Version: version, YieldPercent: yield}
+	if len(lines) > 0 {
+		tree.Version = lines[0].Version
+	}
+	tree.Lines, err = e.walk(lines, yield, 1, 1, map[int]bool{productID: true})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+type explosion struct {
+	src   Source
+	names map[int]string
+}
+
+func (e *explosion) name(productID int) (string, error) {
+	if n, ok := e.names[productID]; ok {
+		return n, nil
+	}
+	p, err := e.src.GetProductById(productID)
+	if err != nil {
+		return "", err
+	}
+	e.names[productID] = p.Name
+	return p.Name, nil
+}
+
+func (e *explosion) walk(lines []types.BoM, yield, parentQty float64, level int, seen map[int]bool) ([]Node, error) {
+	var nodes []Node
+	for _, l := range lines {
+		if seen[l.ComponentID] {
+			return nil, fmt.Errorf("%w: component %d appears in its own structure", ErrCycle, l.ComponentID)
+		}
+		name, err := e.name(l.ComponentID)
+		if err != nil {
+			return nil, err
+		}
+
+		n := Node{
+			BoMID:         l.ID,
+			ComponentID:   l.ComponentID,
+			ComponentName: name,
+			Quantity:      l.Quantity,
+			ScrapPercent:  l.ScrapPercent,
+			Phantom:       l.Phantom,
+			TotalQuantity: GrossQuantity(parentQty*l.Quantity, l.ScrapPercent, yield),
+			OperationName: l.OperationName,
+			OperationID:   l.OperationID,
+			Level:         level,
+		}
+
+		sub, err := e.src.GetBoMVersion(l.ComponentID, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(sub) > 0 {
+			subYield, err := e.src.GetBoMYield(l.ComponentID, 0)
+			if err != nil {
+				return nil, err
+			}
+			seen[l.ComponentID] = true
+			n.Children, err = e.walk(sub, subYield, n.TotalQuantity, level+1, seen)
+			delete(seen, l.ComponentID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// GrossQuantity inflates a net quantity by line scrap and BoM yield, both in percent.
+func GrossQuantity(net, scrapPercent, yieldPercent float64) float64 {
+	if yieldPercent <= 0 {
+		yieldPercent = 100
+	}
+	return net * (1 + scrapPercent/100) * 100 / yieldPercent
+}
+
+// Requirement is one component that has to be supplied to build the root
+// product. Phantom sub-assemblies never show up here; their own components do.
+type Requirement struct {
+	BoMID         int     `json:"bom_id"`
+	ComponentID   int     `json:"component_id"`
+	ComponentName string  `json:"component_name"`
+	Quantity      float64 `json:"quantity"`
+	OperationName string  `json:"operation_name,omitempty"`
+	OperationID   *int    `json:"operation_id,omitempty"`
+	// SubAssembly is set when the component has its own BoM and is planned separately.
+	SubAssembly bool `json:"sub_assembly"`
+}
+
+// Requirements returns the gross requirements per unit of the root product,
+// blowing through phantom lines into their children. Children of a phantom
+// without an operation of their own are consumed at the phantom's operation.
+func (t *Tree) Requirements() []Requirement {
+	var out []Requirement
+	var collect func(nodes []Node, opName string, opID *int)
+	collect = func(nodes []Node, opName string, opID *int) {
+		for _, n := range nodes {
+			if n.OperationName == "" && n.OperationID == nil {
+				n.OperationName, n.OperationID = opName, opID
+			}
+			if n.Phantom && len(n.Children) > 0 {
+				collect(n.Children, n.OperationName, n.OperationID)
+				continue
+			}
+			out = append(out, Requirement{
+				BoMID:         n.BoMID,
+				ComponentID:   n.ComponentID,
+				ComponentName: n.ComponentName,
+				Quantity:      n.TotalQuantity,
+				OperationName: n.OperationName,
+				OperationID:   n.OperationID,
+				SubAssembly:   len(n.Children) > 0,
+			})
+		}
+	}
+	collect(t.Lines, "", nil)
+	return out
+}
@@ -0,0 +1,157 @@
+package bom
+
+import (
+	"errors"
+	"fmt"
+	"mma_api/internal/apperr"
+	"mma_api/internal/types"
+	"testing"
+)
+
+// stubSource serves BoM lines, yields and product names from memory. The
+// lines of every version sit in one slice; version 0 asks for the highest a
+// product has. Products without a yield yield fully.
+type stubSource struct {
+	lines    []types.BoM
+	yields   map[int]float64
+	products map[int]string
+}
+
+func (s stubSource) GetBoMVersion(productID, version int) ([]types.BoM, error) {
+	if version == 0 {
+		for _, l := range s.lines {
+			if l.ProductID == productID && l.Version > version {
+				version = l.Version
+			}
+		}
+	}
+	var out []types.BoM
+	for _, l := range s.lines {
+		if l.ProductID == productID && l.Version == version {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (s stubSource) GetBoMYield(productID, version int) (float64, error) {
+	if y, ok := s.yields[productID]; ok {
+		return y, nil
+	}
+	return 100, nil
+}
+
+func (s stubSource) GetProductById(id int) (*types.Product, error) {
+	name, ok := s.products[id]
+	if !ok {
+		return nil, fmt.Errorf("product with id %d not found", id)
+	}
+	return &types.Product{ID: id, Name: name}, nil
+}
+
+// A bicycle (1) takes two wheels (2) and a frame (3); version 2 adds a bell
+// (5). Each wheel is laced with 32 spokes (4).
+var bicycle = stubSource{
+	lines: []types.BoM{
+		{ID: 1, ProductID: 1, ComponentID: 2, Quantity: 2, Version: 1},
+		{ID: 2, ProductID: 1, ComponentID: 3, Quantity: 1, Version: 1},
+		{ID: 3, ProductID: 1, ComponentID: 2, Quantity: 2, Version: 2},
+		{ID: 4, ProductID: 1, ComponentID: 3, Quantity: 1, Version: 2},
+		{ID: 5, ProductID: 1, ComponentID: 5, Quantity: 1, Version: 2},
+		{ID: 6, ProductID: 2, ComponentID: 4, Quantity: 32, Version: 1},
+	},
+	products: map[int]string{1: "bicycle", 2: "wheel", 3: "frame", 4: "spoke", 5: "bell"},
+}
+
+func TestExplodeLatestVersion(t *testing.T) {
+	tree, err := Explode(bicycle, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.ProductName != "bicycle" || tree.Version != 2 {
+		t.Errorf("tree is %s v%d, want bicycle v2", tree.ProductName, tree.Version)
+	}
+	if len(tree.Lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(tree.Lines))
+	}
+
+	wheel := tree.Lines[0]
+	if wheel.ComponentName != "wheel" || wheel.Level != 1 || len(wheel.Children) != 1 {
+		t.Fatalf("first line = %+v, want the wheel with its spokes", wheel)
+	}
+	spokes := wheel.Children[0]
+	if spokes.Level != 2 || spokes.Quantity != 32 || spokes.TotalQuantity != 64 {
+		t.Errorf("spokes at level %d, %v per wheel, %v per bicycle; want level 2, 32 and 64",
+			spokes.Level, spokes.Quantity, spokes.TotalQuantity)
+	}
+	if bell := tree.Lines[2]; bell.ComponentName != "bell" || len(bell.Children) != 0 {
+		t.Errorf("last line = %+v, want the bell as a bought-in part", bell)
+	}
+}
+
+func TestExplodeScrapAndYield(t *testing.T) {
+	src := bicycle
+	src.yields = map[int]float64{1: 50}
+	src.lines = append([]types.BoM(nil), bicycle.lines...)
+	src.lines[5].ScrapPercent = 25
+
+	tree, err := Explode(src, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.YieldPercent != 50 {
+		t.Errorf("yield = %v, want 50", tree.YieldPercent)
+	}
+	// Half the bicycles come out good, so every one takes four wheels; the
+	// wheels themselves yield fully but lose a quarter of their spokes.
+	wheel := tree.Lines[0]
+	if wheel.TotalQuantity != 4 || wheel.Children[0].TotalQuantity != 160 {
+		t.Errorf("%v wheels with %v spokes, want 4 with 160", wheel.TotalQuantity, wheel.Children[0].TotalQuantity)
+	}
+}
+
+func TestExplodeOlderVersion(t *testing.T) {
+	tree, err := Explode(bicycle, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Version != 1 || len(tree.Lines) != 2 {
+		t.Errorf("got v%d with %d lines, want v1 with 2", tree.Version, len(tree.Lines))
+	}
+}
+
+func TestExplodeUnknownVersion(t *testing.T) {
+	if _, err := Explode(bicycle, 1, 3); !errors.Is(err, apperr.NotFound) {
+		t.Errorf("error = %v, want not found", err)
+	}
+}
+
+func TestExplodeBoughtInPart(t *testing.T) {
+	tree, err := Explode(bicycle, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Lines) != 0 {
+		t.Errorf("a spoke exploded into %d lines, want none", len(tree.Lines))
+	}
+}
+
+func TestExplodeUnknownProduct(t *testing.T) {
+	if _, err := Explode(bicycle, 9, 0); err == nil {
+		t.Error("exploding a product that does not exist succeeded")
+	}
+}
+
+func TestExplodeCycle(t *testing.T) {
+	src := stubSource{
+		lines: []types.BoM{
+			{ID: 1, ProductID: 1, ComponentID: 2, Quantity: 1, Version: 1},
+			{ID: 2, ProductID: 2, ComponentID: 3, Quantity: 1, Version: 1},
+			{ID: 3, ProductID: 3, ComponentID: 1, Quantity: 1, Version: 1},
+		},
+		products: map[int]string{1: "a", 2: "b", 3: "c"},
+	}
+	if _, err := Explode(src, 1, 0); !errors.Is(err, ErrCycle) {
+		t.Errorf("error = %v, want ErrCycle", err)
+	}
+}
@@ -0,0 +1,92 @@
+package bom
+
+import (
+	"math"
+	"mma_api/internal/types"
+	"testing"
+)
+
+func TestGrossQuantity(t *testing.T) {
+	tests := []struct {
+		net, scrap, yield float64
+		want              float64
+	}{
+		{4, 0, 100, 4},
+		{4, 10, 100, 4.4},
+		{4, 0, 80, 5},
+		{4, 10, 80, 5.5},
+		{4, 0, 0, 4},
+		{4, 0, -5, 4},
+	}
+	for _, tt := range tests {
+		if got := GrossQuantity(tt.net, tt.scrap, tt.yield); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("GrossQuantity(%v, %v, %v) = %v, want %v", tt.net, tt.scrap, tt.yield, got, tt.want)
+		}
+	}
+}
+
+func TestRequirementsBlowThroughPhantoms(t *testing.T) {
+	// A table (1) is built from a top (2) it never stocks and four legs (3)
+	// bought in with 5% scrap. The top is two boards (4) and eight screws
+	// (5); only 80% of the tables come out good.
+	src := stubSource{
+		lines: []types.BoM{
+			{ID: 1, ProductID: 1, ComponentID: 2, Quantity: 1, Phantom: true, Version: 1},
+			{ID: 2, ProductID: 1, ComponentID: 3, Quantity: 4, ScrapPercent: 5, Version: 1},
+			{ID: 3, ProductID: 2, ComponentID: 4, Quantity: 2, Version: 1},
+			{ID: 4, ProductID: 2, ComponentID: 5, Quantity: 8, Version: 1},
+		},
+		yields:   map[int]float64{1: 80},
+		products: map[int]string{1: "table", 2: "top", 3: "leg", 4: "board", 5: "screw"},
+	}
+	tree, err := Explode(src, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := tree.Requirements()
+	want := []Requirement{
+		{BoMID: 3, ComponentID: 4, ComponentName: "board", Quantity: 2.5},
+		{BoMID: 4, ComponentID: 5, ComponentName: "screw", Quantity: 10},
+		{BoMID: 2, ComponentID: 3, ComponentName: "leg", Quantity: 5.25},
+	}
+	if len(reqs) != len(want) {
+		t.Fatalf("got %d requirements, want %d: %+v", len(reqs), len(want), reqs)
+	}
+	for i, r := range reqs {
+		r.Quantity = math.Round(r.Quantity*1000) / 1000
+		if r != want[i] {
+			t.Errorf("requirement %d = %+v, want %+v", i, r, want[i])
+		}
+	}
+}
+
+func TestRequirementsKeepStockedSubAssemblies(t *testing.T) {
+	tree := &Tree{Lines: []Node{
+		{BoMID: 1, ComponentID: 2, ComponentName: "wheel", TotalQuantity: 2, Children: []Node{
+			{BoMID: 6, ComponentID: 4, ComponentName: "spoke", TotalQuantity: 64},
+		}},
+	}}
+	reqs := tree.Requirements()
+	if len(reqs) != 1 || reqs[0].ComponentName != "wheel" || !reqs[0].SubAssembly {
+		t.Errorf("requirements = %+v, want the wheel as a sub-assembly", reqs)
+	}
+}
+
+func TestRequirementsTakeThePhantomOperation(t *testing.T) {
+	assemble, fasten := 7, 8
+	tree := &Tree{Lines: []Node{
+		{ComponentName: "top", Phantom: true, OperationName: "assemble", OperationID: &assemble, Children: []Node{
+			{ComponentName: "board", TotalQuantity: 2},
+			{ComponentName: "screw", TotalQuantity: 8, OperationName: "fasten", OperationID: &fasten},
+		}},
+		{ComponentName: "leg", TotalQuantity: 4},
+	}}
+
+	want := map[string]*int{"board": &assemble, "screw": &fasten, "leg": nil}
+	for _, r := range tree.Requirements() {
+		if w := want[r.ComponentName]; r.OperationID != w {
+			t.Errorf("%s is consumed at operation %v, want %v", r.ComponentName, r.OperationID, w)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package calendar
+
+import (
+	"fmt"
+	"math"
+	"mma_api/internal/types"
+	"sort"
+	"time"
+)
+
+// Clock parses an "HH:MM" time of day into minutes after midnight.
+func Clock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, want HH:MM", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ShiftMinutes returns the length of a shift. A shift ending at or before
+// its start runs past midnight.
+func ShiftMinutes(s types.Shift) (int, error) {
+	start, err := Clock(s.Start)
+	if err != nil {
+		return 0, err
+	}
+	end, err := Clock(s.End)
+	if err != nil {
+		return 0, err
+	}
+	if end <= start {
+		end += 24 * 60
+	}
+	return end - start, nil
+}
+
+// weekMinutes is the length of the weekly cycle a shift pattern repeats on.
+const weekMinutes = 7 * 24 * 60
+
+// Clash returns the indexes of the first two shifts of a pattern that overlap
+// in the week, including a night shift running into the next day's first.
+func Clash(shifts []types.Shift) (int, int, bool, error) {
+	type span struct{ start, end int }
+	spans := make([]span, len(shifts))
+	for i, s := range shifts {
+		start, err := Clock(s.Start)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		minutes, err := ShiftMinutes(s)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		begin := (s.Weekday-1)*24*60 + start
+		spans[i] = span{begin, begin + minutes}
+	}
+
+	for i := range spans {
+		for j := i + 1; j < len(spans); j++ {
+			// the week wraps: Sunday's night shift meets Monday morning
+			for _, shift := range []int{-weekMinutes, 0, weekMinutes} {
+				if spans[i].start < spans[j].end+shift && spans[j].start+shift < spans[i].end {
+					return i, j, true, nil
+				}
+			}
+		}
+	}
+	return 0, 0, false, nil
+}
+
+// ISOWeekday numbers the days of the week 1 (Monday) to 7 (Sunday).
+func ISOWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// Capacity spreads a shift pattern over the dates from..to (inclusive),
+// skipping holidays and taking out shift time blocked by planned or running
+// maintenance. units is the number of machines or people working in
+// parallel; less than one counts as one. A nil pattern has no working time.
+func Capacity(workCenterID int, pattern *types.ShiftPattern, holidays []types.Holiday, maintenance []types.MaintenanceTask,
+	units int, from, to time.Time) (types.WorkCenterCapacity, error) {
+	if units < 1 {
+		units = 1
+	}
+	c := types.WorkCenterCapacity{
+		WorkCenterID: workCenterID,
+		From:         from.Format(time.DateOnly),
+		To:           to.Format(time.DateOnly),
+		Units:        units,
+		Days:         []types.CapacityDay{},
+	}
+	if pattern != nil {
+		c.ShiftPatternID = &pattern.ID
+	}
+
+	windows, err := Windows(pattern, holidays, from, to)
+	if err != nil {
+		return c, err
+	}
+	byDate := map[string][]Window{}
+	for _, w := range windows {
+		d := w.Date.Format(time.DateOnly)
+		byDate[d] = append(byDate[d], w)
+	}
+
+	closed := map[string]string{}
+	for _, h := range holidays {
+		closed[h.Date.Format(time.DateOnly)] = h.Name
+	}
+	// overlapping tasks block the same minutes once
+	busy := merge(maintenance)
+
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := types.CapacityDay{Date: d.Format(time.DateOnly)}
+		if name, ok := closed[day.Date]; ok {
+			day.Holiday = name
+			if day.Holiday == "" {
+				day.Holiday = "holiday"
+			}
+		}
+
+		var minutes, blocked float64
+		for _, w := range byDate[day.Date] {
+			minutes += w.End.Sub(w.Start).Minutes()
+			for _, b := range busy {
+				blocked += Overlap(b.start, b.end, w.Start, w.End)
+			}
+		}
+		day.Shifts = len(byDate[day.Date])
+		day.MaintenanceHours = round2(blocked / 60)
+		day.Hours = round2((minutes - blocked) / 60)
+
+		c.ShiftHours += day.Hours
+		c.Days = append(c.Days, day)
+	}
+
+	c.ShiftHours = round2(c.ShiftHours)
+	c.CapacityHours = round2(c.ShiftHours * float64(units))
+	return c, nil
+}
+
+// Overlap returns the minutes [aStart, aEnd) and [bStart, bEnd) share.
+func Overlap(aStart, aEnd, bStart, bEnd time.Time) float64 {
+	start, end := aStart, aEnd
+	if bStart.After(start) {
+		start = bStart
+	}
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Minutes()
+}
+
+type interval struct{ start, end time.Time }
+
+// merge returns the time covered by maintenance tasks as disjoint
+// intervals in start order.
+func merge(tasks []types.MaintenanceTask) []interval {
+	spans := make([]interval, 0, len(tasks))
+	for _, t := range tasks {
+		if t.PlannedEnd.After(t.DueAt) {
+			spans = append(spans, interval{t.DueAt, t.PlannedEnd})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+
+	var out []interval
+	for _, s := range spans {
+		if n := len(out); n > 0 && !s.start.After(out[n-1].end) {
+			if s.end.After(out[n-1].end) {
+				out[n-1].end = s.end
+			}
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Window is one shift as worked on a given date.
+type Window struct {
+	Date  time.Time
+	Shift string
+	Start time.Time
+	End   time.Time
+}
+
+// Windows lays a shift pattern out over the dates from..to (inclusive) as
+// concrete shift windows, skipping holidays, in start order.
+func Windows(pattern *types.ShiftPattern, holidays []types.Holiday, from, to time.Time) ([]Window, error) {
+	if pattern == nil {
+		return nil, nil
+	}
+
+	closed := map[string]bool{}
+	for _, h := range holidays {
+		closed[h.Date.Format(time.DateOnly)] = true
+	}
+
+	var windows []Window
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if closed[d.Format(time.DateOnly)] {
+			continue
+		}
+		for _, s := range pattern.Shifts {
+			if s.Weekday != ISOWeekday(d) {
+				continue
+			}
+			start, err := Clock(s.Start)
+			if err != nil {
+				return nil, err
+			}
+			minutes, err := ShiftMinutes(s)
+			if err != nil {
+				return nil, err
+			}
+			begin := d.Add(time.Duration(start) * time.Minute)
+			windows = append(windows, Window{
+				Date:  d,
+				Shift: s.Name,
+				Start: begin,
+				End:   begin.Add(time.Duration(minutes) * time.Minute),
+			})
+		}
+	}
+	return windows, nil
+}
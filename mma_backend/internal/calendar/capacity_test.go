@@ -0,0 +1,219 @@
+package calendar
+
+import (
+	"mma_api/internal/types"
+	"testing"
+	"time"
+)
+
+// ts reads a UTC time written as "2006-01-02 15:04", or a bare date.
+func ts(v string) time.Time {
+	layout := "2006-01-02 15:04"
+	if len(v) == len(time.DateOnly) {
+		layout = time.DateOnly
+	}
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestClock(t *testing.T) {
+	for v, want := range map[string]int{"00:00": 0, "06:30": 390, "23:59": 1439} {
+		if got, err := Clock(v); err != nil || got != want {
+			t.Errorf("Clock(%q) = %d, %v; want %d", v, got, err, want)
+		}
+	}
+	for _, v := range []string{"24:00", "6:30pm", "7", ""} {
+		if _, err := Clock(v); err == nil {
+			t.Errorf("Clock(%q) accepted a bad time of day", v)
+		}
+	}
+}
+
+func TestShiftMinutes(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       int
+	}{
+		{"06:00", "14:00", 480},
+		{"22:00", "06:00", 480},
+		{"16:00", "00:00", 480},
+		{"07:00", "07:00", 1440},
+	}
+	for _, tt := range tests {
+		got, err := ShiftMinutes(types.Shift{Start: tt.start, End: tt.end})
+		if err != nil || got != tt.want {
+			t.Errorf("shift %s-%s lasts %d minutes (%v), want %d", tt.start, tt.end, got, err, tt.want)
+		}
+	}
+	if _, err := ShiftMinutes(types.Shift{Start: "07:00", End: "25:00"}); err == nil {
+		t.Error("a shift ending at 25:00 was accepted")
+	}
+}
+
+func TestISOWeekday(t *testing.T) {
+	if d := ISOWeekday(ts("2025-06-02")); d != 1 {
+		t.Errorf("Monday is day %d, want 1", d)
+	}
+	if d := ISOWeekday(ts("2025-06-08")); d != 7 {
+		t.Errorf("Sunday is day %d, want 7", d)
+	}
+}
+
+func TestClash(t *testing.T) {
+	early := types.Shift{Weekday: 1, Start: "06:00", End: "14:00"}
+	tests := []struct {
+		name   string
+		shifts []types.Shift
+		i, j   int
+		found  bool
+	}{
+		{"back to back", []types.Shift{early, {Weekday: 1, Start: "14:00", End: "22:00"}}, 0, 0, false},
+		{"same day", []types.Shift{early, {Weekday: 2, Start: "06:00", End: "14:00"}, {Weekday: 1, Start: "13:00", End: "21:00"}}, 0, 2, true},
+		{"night into the next morning", []types.Shift{{Weekday: 1, Start: "22:00", End: "07:00"}, {Weekday: 2, Start: "06:00", End: "14:00"}}, 0, 1, true},
+		{"sunday night into monday", []types.Shift{early, {Weekday: 7, Start: "22:00", End: "06:30"}}, 0, 1, true},
+		{"sunday night up to monday", []types.Shift{early, {Weekday: 7, Start: "22:00", End: "06:00"}}, 0, 0, false},
+	}
+	for _, tt := range tests {
+		i, j, found, err := Clash(tt.shifts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != tt.found || i != tt.i || j != tt.j {
+			t.Errorf("%s: Clash = %d, %d, %v; want %d, %d, %v", tt.name, i, j, found, tt.i, tt.j, tt.found)
+		}
+	}
+}
+
+// earlies works an early shift Monday to Friday and a night shift from
+// Friday into Saturday.
+var earlies = &types.ShiftPattern{ID: 3, Shifts: []types.Shift{
+	{Name: "early", Weekday: 1, Start: "06:00", End: "14:00"},
+	{Name: "early", Weekday: 2, Start: "06:00", End: "14:00"},
+	{Name: "early", Weekday: 3, Start: "06:00", End: "14:00"},
+	{Name: "early", Weekday: 4, Start: "06:00", End: "14:00"},
+	{Name: "early", Weekday: 5, Start: "06:00", End: "14:00"},
+	{Name: "night", Weekday: 5, Start: "22:00", End: "06:00"},
+}}
+
+func sameDays(t *testing.T, got, want []types.CapacityDay) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d days, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("day %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWindows(t *testing.T) {
+	friday, err := Windows(earlies, nil, ts("2025-06-06"), ts("2025-06-07"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Window{
+		{Date: ts("2025-06-06"), Shift: "early", Start: ts("2025-06-06 06:00"), End: ts("2025-06-06 14:00")},
+		{Date: ts("2025-06-06"), Shift: "night", Start: ts("2025-06-06 22:00"), End: ts("2025-06-07 06:00")},
+	}
+	if len(friday) != len(want) {
+		t.Fatalf("got %d windows, want %d", len(friday), len(want))
+	}
+	for i, w := range want {
+		g := friday[i]
+		if !g.Date.Equal(w.Date) || g.Shift != w.Shift || !g.Start.Equal(w.Start) || !g.End.Equal(w.End) {
+			t.Errorf("window %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	week, err := Windows(earlies, []types.Holiday{{Date: ts("2025-06-03")}}, ts("2025-06-02"), ts("2025-06-04"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(week) != 2 || week[0].Date.Day() != 2 || week[1].Date.Day() != 4 {
+		t.Errorf("windows around a Tuesday holiday = %+v, want Monday and Wednesday", week)
+	}
+
+	if none, _ := Windows(nil, nil, ts("2025-06-02"), ts("2025-06-08")); none != nil {
+		t.Errorf("no pattern has windows %+v", none)
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	shift := [2]time.Time{ts("2025-06-02 06:00"), ts("2025-06-02 14:00")}
+	tests := []struct {
+		from, to string
+		want     float64
+	}{
+		{"2025-06-02 03:00", "2025-06-02 05:00", 0},
+		{"2025-06-02 04:00", "2025-06-02 06:00", 0},
+		{"2025-06-02 05:00", "2025-06-02 07:30", 90},
+		{"2025-06-02 09:00", "2025-06-02 10:15", 75},
+		{"2025-06-01 22:00", "2025-06-03 00:00", 480},
+	}
+	for _, tt := range tests {
+		if got := Overlap(ts(tt.from), ts(tt.to), shift[0], shift[1]); got != tt.want {
+			t.Errorf("%s to %s overlaps the shift by %v minutes, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	c, err := Capacity(7, earlies, nil, nil, 2, ts("2025-06-06"), ts("2025-06-07"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ShiftPatternID == nil || *c.ShiftPatternID != 3 || c.Units != 2 {
+		t.Errorf("capacity of pattern %v with %d units, want pattern 3 with 2", c.ShiftPatternID, c.Units)
+	}
+	if c.ShiftHours != 16 || c.CapacityHours != 32 {
+		t.Errorf("hours = %v shift, %v capacity; want 16 and 32", c.ShiftHours, c.CapacityHours)
+	}
+	sameDays(t, c.Days, []types.CapacityDay{
+		{Date: "2025-06-06", Shifts: 2, Hours: 16},
+		{Date: "2025-06-07"},
+	})
+}
+
+func TestCapacityHoliday(t *testing.T) {
+	holidays := []types.Holiday{{Date: ts("2025-06-06")}}
+	c, err := Capacity(7, earlies, holidays, nil, 0, ts("2025-06-05"), ts("2025-06-06"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Units != 1 || c.CapacityHours != 8 {
+		t.Errorf("%v hours on %d units, want 8 on one", c.CapacityHours, c.Units)
+	}
+	sameDays(t, c.Days, []types.CapacityDay{
+		{Date: "2025-06-05", Shifts: 1, Hours: 8},
+		{Date: "2025-06-06", Holiday: "holiday"},
+	})
+}
+
+func TestCapacityWithoutPattern(t *testing.T) {
+	c, err := Capacity(7, nil, nil, nil, 1, ts("2025-06-06"), ts("2025-06-07"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ShiftPatternID != nil || c.CapacityHours != 0 || len(c.Days) != 2 {
+		t.Errorf("capacity without a pattern = %+v, want two empty days", c)
+	}
+}
+
+func TestCapacityLessMaintenance(t *testing.T) {
+	// The first two tasks overlap and block 06:00 to 08:00 once; the third
+	// takes the end of the early shift and the start of the night.
+	maintenance := []types.MaintenanceTask{
+		{DueAt: ts("2025-06-06 05:00"), PlannedEnd: ts("2025-06-06 07:00")},
+		{DueAt: ts("2025-06-06 06:30"), PlannedEnd: ts("2025-06-06 08:00")},
+		{DueAt: ts("2025-06-06 13:30"), PlannedEnd: ts("2025-06-06 23:00")},
+	}
+	c, err := Capacity(7, earlies, nil, maintenance, 1, ts("2025-06-06"), ts("2025-06-06"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameDays(t, c.Days, []types.CapacityDay{{Date: "2025-06-06", Shifts: 2, Hours: 12.5, MaintenanceHours: 3.5}})
+}
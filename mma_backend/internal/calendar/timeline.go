@@ -0,0 +1,121 @@
+package calendar
+
+import (
+	"mma_api/internal/types"
+	"time"
+)
+
+// DayShifts is the pattern assumed for work that has no shift pattern to go
+// by: one eight-hour day shift on every day of the week.
+func DayShifts() *types.ShiftPattern {
+	sp := &types.ShiftPattern{Name: "day shifts"}
+	for d := 1; d <= 7; d++ {
+		sp.Shifts = append(sp.Shifts, types.Shift{Name: "day", Weekday: d, Start: "08:00", End: "16:00"})
+	}
+	return sp
+}
+
+// Outstanding moves planned maintenance that should have started by now to
+// start now, keeping its length, so it goes on blocking working time until
+// somebody starts it.
+func Outstanding(tasks []types.MaintenanceTask, now time.Time) []types.MaintenanceTask {
+	out := make([]types.MaintenanceTask, len(tasks))
+	for i, t := range tasks {
+		if t.Status == "planned" && t.DueAt.Before(now) {
+			t.PlannedEnd = now.Add(t.PlannedEnd.Sub(t.DueAt))
+			t.DueAt = now
+		}
+		out[i] = t
+	}
+	return out
+}
+
+// Timeline is the time a work center can work: its shift windows less the
+// time blocked by maintenance, as disjoint spans in start order.
+type Timeline []interval
+
+// NewTimeline takes maintenance out of shift windows.
+func NewTimeline(windows []Window, maintenance []types.MaintenanceTask) Timeline {
+	busy := merge(maintenance)
+	var t Timeline
+	for _, w := range windows {
+		start := w.Start
+		for _, b := range busy {
+			if !b.end.After(start) || !b.start.Before(w.End) {
+				continue
+			}
+			if b.start.After(start) {
+				t = append(t, interval{start, b.start})
+			}
+			start = b.end
+		}
+		if w.End.After(start) {
+			t = append(t, interval{start, w.End})
+		}
+	}
+	return t
+}
+
+// Next returns the first working time at or after at; ok is false when
+// the timeline has none.
+func (t Timeline) Next(at time.Time) (time.Time, bool) {
+	for _, s := range t {
+		if s.end.After(at) {
+			if s.start.After(at) {
+				return s.start, true
+			}
+			return at, true
+		}
+	}
+	return at, false
+}
+
+// Finish returns when `minutes` of work started at start are done. ok is
+// false when the timeline ends first; the time is then where it ends.
+func (t Timeline) Finish(start time.Time, minutes float64) (time.Time, bool) {
+	at := start
+	for _, s := range t {
+		if minutes <= 0 {
+			break
+		}
+		if !s.end.After(at) {
+			continue
+		}
+		if s.start.After(at) {
+			at = s.start
+		}
+		left := s.end.Sub(at).Minutes()
+		if minutes <= left {
+			return at.Add(time.Duration(minutes * float64(time.Minute))), true
+		}
+		minutes -= left
+		at = s.end
+	}
+	return at, minutes <= 0
+}
+
+// Start returns when `minutes` of work have to start to be done by end. ok
+// is false when the timeline begins too late; the time is then where it
+// begins.
+func (t Timeline) Start(end time.Time, minutes float64) (time.Time, bool) {
+	at := end
+	for i := len(t) - 1; i >= 0; i-- {
+		if minutes <= 0 {
+			break
+		}
+		s := t[i]
+		if !s.start.Before(at) {
+			continue
+		}
+		if s.end.Before(at) {
+			at = s.end
+		}
+		left := at.Sub(s.start).Minutes()
+		if minutes <= left {
+			return at.Add(-time.Duration(minutes * float64(time.Minute))), true
+		}
+		minutes -= left
+		at = s.start
+	}
+	return at, minutes <= 0
+}
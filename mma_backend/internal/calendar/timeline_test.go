@@ -0,0 +1,136 @@
+package calendar
+
+import (
+	"mma_api/internal/types"
+	"testing"
+	"time"
+)
+
+// workshop works 08:00 to 16:00 on two days, with two overlapping
+// maintenance tasks taking 10:00 to 12:00 out of the first.
+func workshop() Timeline {
+	windows := []Window{
+		{Date: ts("2025-06-02"), Start: ts("2025-06-02 08:00"), End: ts("2025-06-02 16:00")},
+		{Date: ts("2025-06-03"), Start: ts("2025-06-03 08:00"), End: ts("2025-06-03 16:00")},
+	}
+	return NewTimeline(windows, []types.MaintenanceTask{
+		{DueAt: ts("2025-06-02 10:00"), PlannedEnd: ts("2025-06-02 11:00")},
+		{DueAt: ts("2025-06-02 10:30"), PlannedEnd: ts("2025-06-02 12:00")},
+	})
+}
+
+func TestNewTimeline(t *testing.T) {
+	want := [][2]string{
+		{"2025-06-02 08:00", "2025-06-02 10:00"},
+		{"2025-06-02 12:00", "2025-06-02 16:00"},
+		{"2025-06-03 08:00", "2025-06-03 16:00"},
+	}
+	got := workshop()
+	if len(got) != len(want) {
+		t.Fatalf("got %d spans, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if !got[i].start.Equal(ts(w[0])) || !got[i].end.Equal(ts(w[1])) {
+			t.Errorf("span %d = %v to %v, want %s to %s", i, got[i].start, got[i].end, w[0], w[1])
+		}
+	}
+}
+
+func TestTimelineNext(t *testing.T) {
+	tl := workshop()
+	tests := []struct {
+		at, want string
+		ok       bool
+	}{
+		{"2025-06-02 09:00", "2025-06-02 09:00", true},
+		{"2025-06-02 10:30", "2025-06-02 12:00", true},
+		{"2025-06-02 20:00", "2025-06-03 08:00", true},
+		{"2025-06-03 16:00", "2025-06-03 16:00", false},
+	}
+	for _, tt := range tests {
+		got, ok := tl.Next(ts(tt.at))
+		if !got.Equal(ts(tt.want)) || ok != tt.ok {
+			t.Errorf("Next(%s) = %v, %v; want %s, %v", tt.at, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTimelineFinish(t *testing.T) {
+	tl := workshop()
+	tests := []struct {
+		start   string
+		minutes float64
+		want    string
+		ok      bool
+	}{
+		{"2025-06-02 09:00", 0, "2025-06-02 09:00", true},
+		{"2025-06-02 08:00", 90, "2025-06-02 09:30", true},
+		{"2025-06-02 09:00", 120, "2025-06-02 13:00", true},
+		{"2025-06-02 17:00", 60, "2025-06-03 09:00", true},
+		{"2025-06-02 09:00", 2000, "2025-06-03 16:00", false},
+	}
+	for _, tt := range tests {
+		got, ok := tl.Finish(ts(tt.start), tt.minutes)
+		if !got.Equal(ts(tt.want)) || ok != tt.ok {
+			t.Errorf("Finish(%s, %v) = %v, %v; want %s, %v", tt.start, tt.minutes, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTimelineStart(t *testing.T) {
+	tl := workshop()
+	tests := []struct {
+		end     string
+		minutes float64
+		want    string
+		ok      bool
+	}{
+		{"2025-06-03 09:00", 0, "2025-06-03 09:00", true},
+		{"2025-06-03 16:00", 60, "2025-06-03 15:00", true},
+		{"2025-06-03 09:00", 120, "2025-06-02 15:00", true},
+		{"2025-06-02 11:00", 60, "2025-06-02 09:00", true},
+		{"2025-06-02 09:00", 120, "2025-06-02 08:00", false},
+	}
+	for _, tt := range tests {
+		got, ok := tl.Start(ts(tt.end), tt.minutes)
+		if !got.Equal(ts(tt.want)) || ok != tt.ok {
+			t.Errorf("Start(%s, %v) = %v, %v; want %s, %v", tt.end, tt.minutes, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestOutstanding(t *testing.T) {
+	now := ts("2025-06-02 12:00")
+	tasks := Outstanding([]types.MaintenanceTask{
+		{Status: "planned", DueAt: ts("2025-06-02 09:00"), PlannedEnd: ts("2025-06-02 10:30")},
+		{Status: "planned", DueAt: ts("2025-06-02 14:00"), PlannedEnd: ts("2025-06-02 15:00")},
+		{Status: "in_progress", DueAt: ts("2025-06-02 09:00"), PlannedEnd: ts("2025-06-02 13:00")},
+	}, now)
+
+	// Only the overdue planned task moves, keeping its ninety minutes.
+	want := [][2]string{
+		{"2025-06-02 12:00", "2025-06-02 13:30"},
+		{"2025-06-02 14:00", "2025-06-02 15:00"},
+		{"2025-06-02 09:00", "2025-06-02 13:00"},
+	}
+	for i, w := range want {
+		if !tasks[i].DueAt.Equal(ts(w[0])) || !tasks[i].PlannedEnd.Equal(ts(w[1])) {
+			t.Errorf("task %d blocks %v to %v, want %s to %s", i, tasks[i].DueAt, tasks[i].PlannedEnd, w[0], w[1])
+		}
+	}
+}
+
+func TestDayShifts(t *testing.T) {
+	windows, err := Windows(DayShifts(), nil, ts("2025-06-02"), ts("2025-06-08"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 7 {
+		t.Fatalf("got %d windows in a week, want 7", len(windows))
+	}
+	for _, w := range windows {
+		if w.Start.Hour() != 8 || w.End.Sub(w.Start) != 8*time.Hour {
+			t.Errorf("window %v to %v, want eight hours from 08:00", w.Start, w.End)
+		}
+	}
+}
@@ -12,6 +12,8 @@ type Config struct {
 	Env         string      `yaml:"env" env-required:"true"`
 	Conn_Str    string      `yaml:"conn_str" env-required:"true"`
 	Http_Server Http_Server `yaml:"http_server"`
+	// Token_Secret signs login tokens; without it they do not survive a restart.
+	Token_Secret string `yaml:"token_secret" env:"TOKEN_SECRET"`
 }
 
 type Http_Server struct {
@@ -0,0 +1,173 @@
+package costing
+
+import (
+	"mma_api/internal/bom"
+	"mma_api/internal/types"
+)
+
+type Source interface {
+	bom.Source
+	GetOperationCosts(productID int) ([]types.OperationCost, error)
+}
+
+// LineCost is the cost one top-level BoM line contributes to a unit of the product.
+type LineCost struct {
+	BoMID         int     `json:"bom_id"`
+	ComponentID   int     `json:"component_id"`
+	ComponentName string  `json:"component_name"`
+	Quantity      float64 `json:"quantity"`
+	MaterialCost  float64 `json:"material_cost"`
+	LabourCost    float64 `json:"labour_cost"`
+	OverheadCost  float64 `json:"overhead_cost"`
+	TotalCost     float64 `json:"total_cost"`
+}
+
+type OperationLine struct {
+	types.OperationCost
+	Hours        float64 `json:"hours"`
+	LabourCost   float64 `json:"labour_cost"`
+	OverheadCost float64 `json:"overhead_cost"`
+}
+
+type Rollup struct {
+	ProductID    int             `json:"product_id"`
+	ProductName  string          `json:"product_name"`
+	LotSize      float64         `json:"lot_size"`
+	MaterialCost float64         `json:"material_cost"`
+	LabourCost   float64         `json:"labour_cost"`
+	OverheadCost float64         `json:"overhead_cost"`
+	TotalCost    float64         `json:"total_cost"`
+	Lines        []LineCost      `json:"lines"`
+	Operations   []OperationLine `json:"operations"`
+}
+
+// Roll computes the standard cost of one unit of productID. Purchased parts
+// contribute their purchase cost to material; every made item (the product
+// itself and each sub-assembly) contributes labour and overhead from its own
+// operations, with setup time spread over lotSize units.
+func Roll(src Source, productID int, lotSize float64) (*Rollup, error) {
+	if lotSize <= 0 {
+		lotSize = 1
+	}
+	tree, err := bom.Explode(src, productID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	r := roller{src: src, lotSize: lotSize, products: map[int]*types.Product{}, ops: map[int][]types.OperationCost{}}
+	rollup := &Rollup{
+		ProductID:   tree.ProductID,
+		ProductName: tree.ProductName,
+		LotSize:     lotSize,
+		Lines:       []LineCost{},
+		Operations:  []OperationLine{},
+	}
+
+	ops, err := r.operations(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		line := r.costOperation(op, 1)
+		rollup.Operations = append(rollup.Operations, line)
+		rollup.LabourCost += line.LabourCost
+		rollup.OverheadCost += line.OverheadCost
+	}
+
+	for _, n := range tree.Lines {
+		c, err := r.node(n)
+		if err != nil {
+			return nil, err
+		}
+		c.TotalCost = c.MaterialCost + c.LabourCost + c.OverheadCost
+		rollup.Lines = append(rollup.Lines, c)
+		rollup.MaterialCost += c.MaterialCost
+		rollup.LabourCost += c.LabourCost
+		rollup.OverheadCost += c.OverheadCost
+	}
+
+	rollup.TotalCost = rollup.MaterialCost + rollup.LabourCost + rollup.OverheadCost
+	return rollup, nil
+}
+
+type roller struct {
+	src      Source
+	lotSize  float64
+	products map[int]*types.Product
+	ops      map[int][]types.OperationCost
+}
+
+// node costs a subtree; quantities in the tree are already per root unit.
+func (r *roller) node(n bom.Node) (LineCost, error) {
+	c := LineCost{
+		BoMID:         n.BoMID,
+		ComponentID:   n.ComponentID,
+		ComponentName: n.ComponentName,
+		Quantity:      n.TotalQuantity,
+	}
+
+	if len(n.Children) == 0 {
+		p, err := r.product(n.ComponentID)
+		if err != nil {
+			return c, err
+		}
+		c.MaterialCost = n.TotalQuantity * p.PurchaseCost
+		return c, nil
+	}
+
+	ops, err := r.operations(n.ComponentID)
+	if err != nil {
+		return c, err
+	}
+	for _, op := range ops {
+		line := r.costOperation(op, n.TotalQuantity)
+		c.LabourCost += line.LabourCost
+		c.OverheadCost += line.OverheadCost
+	}
+	for _, child := range n.Children {
+		cc, err := r.node(child)
+		if err != nil {
+			return c, err
+		}
+		c.MaterialCost += cc.MaterialCost
+		c.LabourCost += cc.LabourCost
+		c.OverheadCost += cc.OverheadCost
+	}
+	return c, nil
+}
+
+func (r *roller) costOperation(op types.OperationCost, quantity float64) OperationLine {
+	// setup is paid once per lot of the root, however many of this item
+	// one root unit takes
+	hours := (op.SetupMinutes/r.lotSize + op.RunMinutesPerUnit*quantity) / 60
+	return OperationLine{
+		OperationCost: op,
+		Hours:         hours,
+		LabourCost:    hours * op.LabourRate,
+		OverheadCost:  hours * op.OverheadRate,
+	}
+}
+
+func (r *roller) product(id int) (*types.Product, error) {
+	if p, ok := r.products[id]; ok {
+		return p, nil
+	}
+	p, err := r.src.GetProductById(id)
+	if err != nil {
+		return nil, err
+	}
+	r.products[id] = p
+	return p, nil
+}
+
+func (r *roller) operations(productID int) ([]types.OperationCost, error) {
+	if ops, ok := r.ops[productID]; ok {
+		return ops, nil
+	}
+	ops, err := r.src.GetOperationCosts(productID)
+	if err != nil {
+		return nil, err
+	}
+	r.ops[productID] = ops
+	return ops, nil
+}
@@ -0,0 +1,155 @@
+package costing
+
+import (
+	"fmt"
+	"math"
+	"mma_api/internal/types"
+	"testing"
+)
+
+// memStore holds a tiny catalogue for rollups: one BoM version per product,
+// full yields and the operations each product is made with.
+type memStore struct {
+	products   []types.Product
+	lines      []types.BoM
+	operations map[int][]types.OperationCost
+}
+
+func (m *memStore) GetBoMVersion(productID, version int) ([]types.BoM, error) {
+	var out []types.BoM
+	for _, l := range m.lines {
+		if l.ProductID == productID {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) GetBoMYield(productID, version int) (float64, error) {
+	return 100, nil
+}
+
+func (m *memStore) GetProductById(id int) (*types.Product, error) {
+	for i := range m.products {
+		if m.products[i].ID == id {
+			return &m.products[i], nil
+		}
+	}
+	return nil, fmt.Errorf("product with id %d not found", id)
+}
+
+func (m *memStore) GetOperationCosts(productID int) ([]types.OperationCost, error) {
+	return m.operations[productID], nil
+}
+
+// lampShop assembles a lamp (1) from a bought shade (2) and a base (3) it
+// drills itself out of two bought bolts (4).
+func lampShop() *memStore {
+	return &memStore{
+		products: []types.Product{
+			{ID: 1, Name: "lamp"},
+			{ID: 2, Name: "shade", PurchaseCost: 5},
+			{ID: 3, Name: "base"},
+			{ID: 4, Name: "bolt", PurchaseCost: 0.5},
+		},
+		lines: []types.BoM{
+			{ID: 1, ProductID: 1, ComponentID: 2, Quantity: 1, Version: 1},
+			{ID: 2, ProductID: 1, ComponentID: 3, Quantity: 1, Version: 1},
+			{ID: 3, ProductID: 3, ComponentID: 4, Quantity: 2, Version: 1},
+		},
+		operations: map[int][]types.OperationCost{
+			1: {{OperationID: 1, Name: "assemble", SetupMinutes: 30, RunMinutesPerUnit: 6, LabourRate: 60, OverheadRate: 30}},
+			3: {{OperationID: 2, Name: "drill", RunMinutesPerUnit: 12, LabourRate: 30}},
+		},
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestRollSingleUnit(t *testing.T) {
+	r, err := Roll(lampShop(), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 for the shade and 1 for the bolts; assembling takes 36 minutes at
+	// 60 + 30 an hour and drilling 12 minutes at 30.
+	if !near(r.MaterialCost, 6) || !near(r.LabourCost, 42) || !near(r.OverheadCost, 18) || !near(r.TotalCost, 66) {
+		t.Errorf("costs %v + %v + %v = %v, want 6 + 42 + 18 = 66",
+			r.MaterialCost, r.LabourCost, r.OverheadCost, r.TotalCost)
+	}
+
+	if len(r.Operations) != 1 || r.Operations[0].Name != "assemble" || !near(r.Operations[0].Hours, 0.6) {
+		t.Errorf("operations = %+v, want assemble for 0.6 hours", r.Operations)
+	}
+	if len(r.Lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(r.Lines))
+	}
+	shade := LineCost{BoMID: 1, ComponentID: 2, ComponentName: "shade", Quantity: 1, MaterialCost: 5, TotalCost: 5}
+	base := LineCost{BoMID: 2, ComponentID: 3, ComponentName: "base", Quantity: 1, MaterialCost: 1, LabourCost: 6, TotalCost: 7}
+	if r.Lines[0] != shade || r.Lines[1] != base {
+		t.Errorf("lines = %+v, want %+v and %+v", r.Lines, shade, base)
+	}
+}
+
+func TestRollSpreadsSetupOverTheLot(t *testing.T) {
+	r, err := Roll(lampShop(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(r.LabourCost, 15) || !near(r.OverheadCost, 4.5) || !near(r.TotalCost, 25.5) {
+		t.Errorf("labour %v, overhead %v, total %v; want 15, 4.5 and 25.5", r.LabourCost, r.OverheadCost, r.TotalCost)
+	}
+
+	one, err := Roll(lampShop(), 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one.LotSize != 1 || !near(one.TotalCost, 66) {
+		t.Errorf("a lot of 0 costs %v as a lot of %v, want 66 as a lot of 1", one.TotalCost, one.LotSize)
+	}
+}
+
+func TestRollSetupOncePerLot(t *testing.T) {
+	shop := lampShop()
+	shop.lines[1].Quantity = 2
+	shop.operations[3][0].SetupMinutes = 30
+
+	r, err := Roll(shop, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two bases a lamp still set the drill up once for a lot of ten lamps:
+	// 3 minutes of setup and 24 of drilling at 30 an hour.
+	if base := r.Lines[1]; !near(base.LabourCost, 13.5) || !near(base.MaterialCost, 2) {
+		t.Errorf("bases cost %v labour and %v material, want 13.5 and 2", base.LabourCost, base.MaterialCost)
+	}
+	if !near(r.LabourCost, 22.5) || !near(r.TotalCost, 34) {
+		t.Errorf("labour %v, total %v; want 22.5 and 34", r.LabourCost, r.TotalCost)
+	}
+}
+
+func TestRollSubAssembly(t *testing.T) {
+	r, err := Roll(lampShop(), 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(r.MaterialCost, 1) || !near(r.LabourCost, 6) || !near(r.TotalCost, 7) {
+		t.Errorf("base costs %v + %v = %v, want 1 + 6 = 7", r.MaterialCost, r.LabourCost, r.TotalCost)
+	}
+}
+
+func TestRollBoughtInPart(t *testing.T) {
+	r, err := Roll(lampShop(), 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.TotalCost != 0 || len(r.Lines) != 0 || len(r.Operations) != 0 {
+		t.Errorf("rollup of a shade = %+v, want nothing to add up", r)
+	}
+}
+
+func TestRollUnknownProduct(t *testing.T) {
+	if _, err := Roll(lampShop(), 9, 1); err == nil {
+		t.Error("rolling up a product that does not exist succeeded")
+	}
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/storage"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func parseUserID(r *http.Request) (int, error) {
+	// URL: /api/users/{id}[/...]
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		return 0, fmt.Errorf("missing user ID")
+	}
+
+	id, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID: %w", err)
+	}
+	return id, nil
+}
+
+type QualificationRequest struct {
+	WorkCenterID int `json:"work_center_id"`
+}
+
+func AddQualificationHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := parseUserID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !RequireManager(storage, w, r, "change qualifications") {
+			return
+		}
+
+		var req QualificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.WorkCenterID == 0 {
+			resp := response.GeneralError(fmt.Errorf("work_center_id is required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		user, err := storage.GetUserByID(userID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusNotFound, resp)
+			return
+		}
+		if user.Role != "worker" {
+			resp := response.GeneralError(fmt.Errorf("user %d is not a worker", user.ID))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		q, err := storage.AddQualification(userID, req.WorkCenterID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          q,
+		})
+	}
+}
+
+func GetQualificationsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := parseUserID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		qualifications, err := storage.GetQualifications(userID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          qualifications,
+		})
+	}
+}
+
+func DeleteQualificationHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/users/{id}/qualifications/{wcId}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 6 {
+			resp := response.GeneralError(fmt.Errorf("missing work center ID"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		userID, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid user ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		wcID, err := strconv.Atoi(pathParts[5])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid work center ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !RequireManager(storage, w, r, "change qualifications") {
+			return
+		}
+
+		if err := storage.DeleteQualification(userID, wcID); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "qualification removed successfully",
+		})
+	}
+}
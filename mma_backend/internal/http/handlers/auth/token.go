@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenTTL is how long a login token stays valid.
+const tokenTTL = 12 * time.Hour
+
+var errInvalidToken = errors.New("invalid or expired token")
+
+// tokenKey signs login tokens. It is random unless SetTokenKey is called, so
+// tokens do not outlive the process then.
+var tokenKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// SetTokenKey sets the secret login tokens are signed with; an empty secret
+// keeps the random one.
+func SetTokenKey(secret string) {
+	if secret != "" {
+		tokenKey = []byte(secret)
+	}
+}
+
+// issueToken returns a token naming userID that expires tokenTTL after now:
+// "<user id>.<expiry unix seconds>.<signature>".
+func issueToken(userID int, now time.Time) string {
+	payload := fmt.Sprintf("%d.%d", userID, now.Add(tokenTTL).Unix())
+	return payload + "." + sign(payload)
+}
+
+// parseToken checks the signature and expiry of a token and returns the user
+// id it names.
+func parseToken(token string, now time.Time) (int, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 || !hmac.Equal([]byte(token[i+1:]), []byte(sign(token[:i]))) {
+		return 0, errInvalidToken
+	}
+	idStr, expStr, ok := strings.Cut(token[:i], ".")
+	if !ok {
+		return 0, errInvalidToken
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, errInvalidToken
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil || !now.Before(time.Unix(exp, 0)) {
+		return 0, errInvalidToken
+	}
+	return id, nil
+}
+
+func sign(payload string) string {
+	mac := hmac.New(sha256.New, tokenKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
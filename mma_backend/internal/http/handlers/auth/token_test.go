@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToken(t *testing.T) {
+	issued := time.Date(2025, 4, 7, 6, 0, 0, 0, time.UTC)
+	token := issueToken(42, issued)
+
+	if id, err := parseToken(token, issued.Add(time.Hour)); err != nil || id != 42 {
+		t.Errorf("parseToken = %d, %v; want 42", id, err)
+	}
+	if _, err := parseToken(token, issued.Add(tokenTTL)); err == nil {
+		t.Error("an expired token was accepted")
+	}
+
+	// Changing the user id breaks the signature.
+	forged := "1" + token[2:]
+	if _, err := parseToken(forged, issued); err == nil {
+		t.Errorf("forged token %q was accepted", forged)
+	}
+	for _, bad := range []string{"", "42", "42.abc", "x.1.sig"} {
+		if _, err := parseToken(bad, issued); err == nil {
+			t.Errorf("parseToken(%q) succeeded", bad)
+		}
+	}
+}
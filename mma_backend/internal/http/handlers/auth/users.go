@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"mma_api/internal/storage"
 	"mma_api/internal/storage/postgres"
+	"mma_api/internal/types"
 	"mma_api/internal/utils/response"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -81,6 +83,8 @@ type LoginResponse struct {
 	UserID  int    `json:"user_id"`
 	Name    string `json:"name"`
 	Role    string `json:"role"`
+	// Token goes back in the Authorization header as "Bearer <token>".
+	Token string `json:"token"`
 }
 
 func Login_handler(storage *postgres.Postgres) http.HandlerFunc {
@@ -107,6 +111,7 @@ func Login_handler(storage *postgres.Postgres) http.HandlerFunc {
 			UserID:  user.ID,
 			Name:    user.Name,
 			Role:    user.Role,
+			Token:   issueToken(user.ID, time.Now()),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -310,3 +315,43 @@ func GetProductsHandler(storage *postgres.Postgres) http.HandlerFunc {
 		})
 	}
 }
+
+// CurrentUser identifies the caller from the bearer token issued at login.
+func CurrentUser(storage storage.Storage, r *http.Request) (*types.User, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, fmt.Errorf("missing bearer token")
+	}
+	id, err := parseToken(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	user, err := storage.GetUserByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("unknown user %d", id)
+	}
+	return user, nil
+}
+
+// IsManager reports whether a user may act on behalf of others on the shop
+// floor.
+func IsManager(user *types.User) bool {
+	return user.Role == "manager" || user.Role == "admin"
+}
+
+// RequireManager writes a 401 or 403 and returns false unless the caller is a
+// manager; action completes "only managers can ..." in the 403.
+func RequireManager(storage storage.Storage, w http.ResponseWriter, r *http.Request, action string) bool {
+	actor, err := CurrentUser(storage, r)
+	if err != nil {
+		resp := response.GeneralError(err)
+		_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+		return false
+	}
+	if !IsManager(actor) {
+		resp := response.GeneralError(fmt.Errorf("only managers can %s", action))
+		_ = response.WriteJson(w, http.StatusForbidden, resp)
+		return false
+	}
+	return true
+}
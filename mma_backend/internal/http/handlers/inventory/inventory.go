@@ -0,0 +1,135 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/storage"
+	"mma_api/internal/trace"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+)
+
+type MovementRequest struct {
+	ProductID     int     `json:"product_id"`
+	MovementType  string  `json:"movement_type"`
+	Quantity      float64 `json:"quantity"`
+	ReferenceType string  `json:"reference_type,omitempty"`
+	ReferenceID   *int    `json:"reference_id,omitempty"`
+	LotNumber     string  `json:"lot_number,omitempty"`
+	SerialNumber  string  `json:"serial_number,omitempty"`
+}
+
+// PostMovementHandler records a receipt (IN) or issue (OUT) of stock. An OUT
+// of a tracked product without a lot or serial takes the oldest lots first.
+func PostMovementHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MovementRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.ProductID == 0 || req.Quantity <= 0 {
+			resp := response.GeneralError(fmt.Errorf("product_id and a positive quantity are required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.MovementType != "IN" && req.MovementType != "OUT" {
+			resp := response.GeneralError(fmt.Errorf("movement_type must be IN or OUT"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		m := types.Inventory{
+			ProductID:    req.ProductID,
+			MovementType: req.MovementType,
+			Quantity:     req.Quantity,
+			ReferenceID:  req.ReferenceID,
+		}
+		if req.ReferenceType != "" {
+			m.ReferenceType = &req.ReferenceType
+		}
+		if req.LotNumber != "" {
+			m.LotNumber = &req.LotNumber
+		}
+		if req.SerialNumber != "" {
+			m.SerialNumber = &req.SerialNumber
+		}
+
+		posted, err := storage.PostMovement(m)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          posted,
+		})
+	}
+}
+
+// GetLotsHandler lists the lots and serials of a product in stock.
+// URL: /api/inventory/lots?product_id=1
+func GetLotsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := strconv.Atoi(r.URL.Query().Get("product_id"))
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid product_id: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		lots, err := storage.GetLotBalances(productID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          lots,
+		})
+	}
+}
+
+// TraceHandler walks the genealogy of a lot or serial number.
+// URL: /api/inventory/trace/{backward|forward}?product_id=1&lot_number=L1 (or &serial_number=S1)
+// Backward lists the component lots in a unit, forward the units a lot went into.
+func TraceHandler(storage storage.Storage, forward bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		productID, err := strconv.Atoi(q.Get("product_id"))
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid product_id: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		lot, serial := q.Get("lot_number"), q.Get("serial_number")
+		if (lot == "") == (serial == "") {
+			resp := response.GeneralError(fmt.Errorf("exactly one of lot_number and serial_number is required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		walk := trace.Backward
+		if forward {
+			walk = trace.Forward
+		}
+		tree, err := walk(storage, productID, lot, serial)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          tree,
+		})
+	}
+}
@@ -0,0 +1,420 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func pathID(r *http.Request, what string) (int, error) {
+	// URL: /api/{work-centers|maintenance-plans}/{id}[/...] or /api/maintenance/tasks/{id}[/...]
+	pathParts := strings.Split(r.URL.Path, "/")
+	i := 3
+	if len(pathParts) > 2 && pathParts[2] == "maintenance" {
+		i = 4
+	}
+	if len(pathParts) <= i {
+		return 0, fmt.Errorf("missing %s ID", what)
+	}
+
+	id, err := strconv.Atoi(pathParts[i])
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s ID: %w", what, err)
+	}
+	return id, nil
+}
+
+// PlanRequest describes a maintenance plan: every interval_days for trigger
+// calendar, every interval_hours of run time for trigger run_hours.
+type PlanRequest struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	Trigger       string   `json:"trigger"`
+	IntervalDays  *int     `json:"interval_days,omitempty"`
+	IntervalHours *float64 `json:"interval_hours,omitempty"`
+	// DurationMinutes is how long the machine is blocked, 60 by default.
+	DurationMinutes int `json:"duration_minutes,omitempty"`
+	// Active defaults to true.
+	Active *bool `json:"active,omitempty"`
+}
+
+func (req PlanRequest) toPlan() (types.MaintenancePlan, error) {
+	plan := types.MaintenancePlan{
+		Name:            strings.TrimSpace(req.Name),
+		Description:     req.Description,
+		Trigger:         req.Trigger,
+		DurationMinutes: req.DurationMinutes,
+		Active:          true,
+	}
+	if plan.Name == "" {
+		return plan, fmt.Errorf("name is required")
+	}
+	switch req.Trigger {
+	case manufacturing.TriggerCalendar:
+		if req.IntervalDays == nil || *req.IntervalDays <= 0 {
+			return plan, fmt.Errorf("calendar plans need interval_days greater than 0")
+		}
+		plan.IntervalDays = req.IntervalDays
+	case manufacturing.TriggerRunHours:
+		if req.IntervalHours == nil || *req.IntervalHours <= 0 {
+			return plan, fmt.Errorf("run_hours plans need interval_hours greater than 0")
+		}
+		plan.IntervalHours = req.IntervalHours
+	default:
+		return plan, fmt.Errorf("trigger must be calendar or run_hours")
+	}
+	if plan.DurationMinutes == 0 {
+		plan.DurationMinutes = 60
+	}
+	if plan.DurationMinutes < 0 {
+		return plan, fmt.Errorf("duration_minutes must be greater than 0")
+	}
+	if req.Active != nil {
+		plan.Active = *req.Active
+	}
+	return plan, nil
+}
+
+func CreatePlanHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/work-centers/{id}/maintenance-plans
+		workCenterID, err := pathID(r, "work center")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "plan maintenance") {
+			return
+		}
+
+		var req PlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		plan, err := req.toPlan()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		plan.WorkCenterID = workCenterID
+
+		created, err := storage.CreateMaintenancePlan(plan)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+func GetPlansHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		workCenterID, err := pathID(r, "work center")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		plans, err := storage.GetMaintenancePlans(workCenterID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          plans,
+		})
+	}
+}
+
+func UpdatePlanHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/maintenance-plans/{id}
+		id, err := pathID(r, "maintenance plan")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "plan maintenance") {
+			return
+		}
+
+		var req PlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		plan, err := req.toPlan()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		plan.ID = id
+
+		updated, err := storage.UpdateMaintenancePlan(plan)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          updated,
+		})
+	}
+}
+
+func DeletePlanHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r, "maintenance plan")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "plan maintenance") {
+			return
+		}
+
+		if err := storage.DeleteMaintenancePlan(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "maintenance plan removed successfully",
+		})
+	}
+}
+
+// GenerateTasksHandler creates every maintenance task that has come due. It
+// is meant to be called periodically; tasks are also generated as plans
+// change, maintenance completes and work orders log run time.
+func GenerateTasksHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RequireManager(storage, w, r, "plan maintenance") {
+			return
+		}
+
+		created, err := storage.GenerateMaintenanceTasks()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+// GetTasksHandler lists maintenance tasks, filtered by ?work_center_id= and
+// ?status=planned|in_progress|done.
+func GetTasksHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		var workCenterID *int
+		if v := q.Get("work_center_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid work_center_id: %w", err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+			workCenterID = &id
+		}
+		status := q.Get("status")
+		if status != "" && status != manufacturing.TaskPlanned && status != manufacturing.TaskInProgress && status != manufacturing.TaskDone {
+			resp := response.GeneralError(fmt.Errorf("status must be planned, in_progress or done"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		tasks, err := storage.GetMaintenanceTasks(workCenterID, status)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          tasks,
+		})
+	}
+}
+
+// GetHistoryHandler lists the completed maintenance of a work center, most
+// recent first.
+func GetHistoryHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/work-centers/{id}/maintenance/history
+		workCenterID, err := pathID(r, "work center")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if _, err := storage.GetWorkCenterByID(workCenterID); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+		tasks, err := storage.GetMaintenanceTasks(&workCenterID, manufacturing.TaskDone)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          tasks,
+		})
+	}
+}
+
+type RescheduleRequest struct {
+	DueAt string `json:"due_at"`
+}
+
+func RescheduleTaskHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/maintenance/tasks/{id}
+		id, err := pathID(r, "maintenance task")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "plan maintenance") {
+			return
+		}
+
+		var req RescheduleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		dueAt, err := time.Parse(time.RFC3339, req.DueAt)
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid due_at: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		task, err := storage.RescheduleMaintenanceTask(id, dueAt)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          task,
+		})
+	}
+}
+
+func StartTaskHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r, "maintenance task")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if _, err := auth.CurrentUser(storage, r); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		task, err := storage.StartMaintenanceTask(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          task,
+		})
+	}
+}
+
+type CompleteRequest struct {
+	Notes string `json:"notes,omitempty"`
+}
+
+// CompleteTaskHandler finishes maintenance; the body with notes is optional.
+func CompleteTaskHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r, "maintenance task")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		var req CompleteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		task, err := storage.CompleteMaintenanceTask(id, actor.ID, req.Notes)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          task,
+		})
+	}
+}
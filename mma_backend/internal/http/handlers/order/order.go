@@ -0,0 +1,873 @@
+package order
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/planning"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OrderRequest struct {
+	ProductID         int    `json:"product_id"`
+	Quantity          int    `json:"quantity"`
+	StartDate         string `json:"start_date"`
+	DueDate           string `json:"due_date,omitempty"`
+	AssignedManagerID *int   `json:"assigned_manager_id,omitempty"`
+	// Priority defaults to normal on create and is left as is on update.
+	Priority *int `json:"priority,omitempty"`
+}
+
+func validPriority(p int) error {
+	if p < manufacturing.PriorityLow || p > manufacturing.PriorityUrgent {
+		return fmt.Errorf("priority must be between %d and %d", manufacturing.PriorityLow, manufacturing.PriorityUrgent)
+	}
+	return nil
+}
+
+// parseDate accepts plain dates ("2025-01-31") as well as RFC 3339 timestamps.
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// toOrder validates the request against the rules every order must satisfy
+// and returns the order it describes.
+func (req OrderRequest) toOrder(storage storage.Storage) (types.ManufacturingOrder, error) {
+	var mo types.ManufacturingOrder
+
+	if req.ProductID == 0 || req.StartDate == "" {
+		return mo, fmt.Errorf("product_id and start_date are required")
+	}
+	if req.Quantity <= 0 {
+		return mo, fmt.Errorf("quantity must be greater than 0")
+	}
+
+	start, err := parseDate(req.StartDate)
+	if err != nil {
+		return mo, fmt.Errorf("invalid start_date: %w", err)
+	}
+	mo = types.ManufacturingOrder{
+		ProductID:         req.ProductID,
+		Quantity:          req.Quantity,
+		Priority:          manufacturing.PriorityNormal,
+		StartDate:         start,
+		AssignedManagerID: req.AssignedManagerID,
+	}
+	if req.Priority != nil {
+		if err := validPriority(*req.Priority); err != nil {
+			return mo, err
+		}
+		mo.Priority = *req.Priority
+	}
+
+	if req.DueDate != "" {
+		due, err := parseDate(req.DueDate)
+		if err != nil {
+			return mo, fmt.Errorf("invalid due_date: %w", err)
+		}
+		if due.Before(start) {
+			return mo, fmt.Errorf("due_date cannot be before start_date")
+		}
+		mo.DueDate = &due
+	}
+
+	if _, err := storage.GetProductById(req.ProductID); err != nil {
+		return mo, err
+	}
+	boms, err := storage.GetBoM(req.ProductID)
+	if err != nil {
+		return mo, err
+	}
+	if len(boms) == 0 {
+		return mo, fmt.Errorf("product %d has no bill of materials", req.ProductID)
+	}
+
+	if req.AssignedManagerID != nil {
+		manager, err := storage.GetUserByID(*req.AssignedManagerID)
+		if err != nil {
+			return mo, err
+		}
+		if manager.Role != "manager" {
+			return mo, fmt.Errorf("user %d is not a manager", manager.ID)
+		}
+	}
+
+	return mo, nil
+}
+
+func parseOrderID(r *http.Request) (int, error) {
+	// URL: /api/manufacturing-orders/{id}[/...]
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		return 0, fmt.Errorf("missing manufacturing order ID")
+	}
+
+	id, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		return 0, fmt.Errorf("invalid manufacturing order ID: %w", err)
+	}
+	return id, nil
+}
+
+func CreateOrderHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req OrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mo, err := req.toOrder(storage)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		mo.Status = manufacturing.StatusDraft
+
+		created, err := storage.CreateMO(mo)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+// parseFilter reads the listing filters from the query string:
+// status (comma separated), product_id, manager_id, start_from, start_to,
+// due_from and due_to. The late and at_risk flags are returned separately as
+// they are computed after the query.
+func parseFilter(r *http.Request) (types.MOFilter, string, string, error) {
+	q := r.URL.Query()
+	var filter types.MOFilter
+
+	if v := q.Get("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+	for key, dst := range map[string]*int{"product_id": &filter.ProductID, "manager_id": &filter.ManagerID} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, "", "", fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*dst = n
+	}
+	for key, dst := range map[string]**time.Time{
+		"start_from": &filter.StartFrom,
+		"start_to":   &filter.StartTo,
+		"due_from":   &filter.DueFrom,
+		"due_to":     &filter.DueTo,
+	} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+		t, err := parseDate(v)
+		if err != nil {
+			return filter, "", "", fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*dst = &t
+	}
+
+	late, atRisk := q.Get("late"), q.Get("at_risk")
+	for key, v := range map[string]string{"late": late, "at_risk": atRisk} {
+		if v != "" && v != "true" && v != "false" {
+			return filter, "", "", fmt.Errorf("%s must be true or false", key)
+		}
+	}
+	return filter, late, atRisk, nil
+}
+
+// listOrders runs the filtered query, sets the computed flags and applies the
+// late and at_risk filters.
+func listOrders(storage storage.Storage, filter types.MOFilter, late, atRisk string) ([]types.MOSummary, error) {
+	mos, err := storage.GetMOs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// the work of every open order is laid out on the timelines of its work
+	// centers up to the latest due date
+	now := time.Now()
+	until := now
+	var steps []types.WorkStep
+	for _, mo := range mos {
+		if mo.DueDate != nil && !manufacturing.Closed(mo.Status) {
+			steps = append(steps, mo.RemainingWork...)
+			if end := mo.DueDate.AddDate(0, 0, 1); end.After(until) {
+				until = end
+			}
+		}
+	}
+	timelines, err := storage.GetWorkStepTimelines(steps, until)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []types.MOSummary{}
+	for _, mo := range mos {
+		mo.Late = manufacturing.Late(mo.Status, mo.DueDate, now)
+		mo.AtRisk = manufacturing.AtRisk(mo.Status, mo.DueDate, mo.RemainingWork, timelines, now)
+		if late != "" && mo.Late != (late == "true") {
+			continue
+		}
+		if atRisk != "" && mo.AtRisk != (atRisk == "true") {
+			continue
+		}
+		out = append(out, mo)
+	}
+	return out, nil
+}
+
+// GetOrdersHandler lists orders, e.g. /api/manufacturing-orders?status=confirmed,in_progress&late=true
+func GetOrdersHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, late, atRisk, err := parseFilter(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mos, err := listOrders(storage, filter, late, atRisk)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          mos,
+		})
+	}
+}
+
+// boardStatuses are the board columns, in display order.
+var boardStatuses = []string{
+	manufacturing.StatusDraft,
+	manufacturing.StatusConfirmed,
+	manufacturing.StatusInProgress,
+	manufacturing.StatusOnHold,
+	manufacturing.StatusDone,
+	manufacturing.StatusCancelled,
+}
+
+// GetBoardHandler returns the filtered orders grouped into one column per
+// status, with counts, so a kanban board can be drawn from a single call.
+// It takes the same filters as GetOrdersHandler.
+func GetBoardHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, late, atRisk, err := parseFilter(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mos, err := listOrders(storage, filter, late, atRisk)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		counts := map[string]int{}
+		columns := map[string][]types.MOSummary{}
+		for _, status := range boardStatuses {
+			counts[status] = 0
+			columns[status] = []types.MOSummary{}
+		}
+		lateCount, atRiskCount := 0, 0
+		for _, mo := range mos {
+			counts[mo.Status]++
+			columns[mo.Status] = append(columns[mo.Status], mo)
+			if mo.Late {
+				lateCount++
+			}
+			if mo.AtRisk {
+				atRiskCount++
+			}
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data": map[string]interface{}{
+				"statuses": boardStatuses,
+				"counts":   counts,
+				"columns":  columns,
+				"total":    len(mos),
+				"late":     lateCount,
+				"at_risk":  atRiskCount,
+			},
+		})
+	}
+}
+
+func GetOrderByIDHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mo, err := storage.GetMOByID(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          mo,
+		})
+	}
+}
+
+func UpdateOrderHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req OrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mo, err := req.toOrder(storage)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		mo.ID = id
+		if req.Priority == nil {
+			current, err := storage.GetMOByID(id)
+			if err != nil {
+				resp := response.GeneralError(err)
+				_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+				return
+			}
+			mo.Priority = current.Priority
+		}
+
+		updated, err := storage.UpdateMO(mo)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          updated,
+		})
+	}
+}
+
+// TransitionHandler applies one state machine action, e.g. POST /api/manufacturing-orders/{id}/confirm.
+// Illegal transitions and unmet guards are rejected with 409.
+func TransitionHandler(storage storage.Storage, action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		mo, err := storage.TransitionMO(id, action, actor.ID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          mo,
+		})
+	}
+}
+
+func GetTransitionsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if _, err := storage.GetMOByID(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		history, err := storage.GetMOTransitions(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          history,
+		})
+	}
+}
+
+func GetOrderWorkOrdersHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wos, err := storage.GetWorkOrdersByMO(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wos,
+		})
+	}
+}
+
+// GetOrderAvailabilityHandler explodes the BoM of an order and compares each
+// component with the stock not reserved by other orders.
+func GetOrderAvailabilityHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mo, err := storage.GetMOByID(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		freeStock := func(productID int) (float64, error) { return storage.GetFreeStock(productID, mo.ID) }
+		lines, err := planning.Availability(storage, freeStock, mo.ProductID, float64(mo.Quantity))
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		reservations, err := storage.GetMOReservations(mo.ID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data": map[string]interface{}{
+				"components":   lines,
+				"reservations": reservations,
+			},
+		})
+	}
+}
+
+func ReserveMaterialsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		reservations, err := storage.ReserveMaterials(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          reservations,
+		})
+	}
+}
+
+func GetReservationsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if _, err := storage.GetMOByID(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		reservations, err := storage.GetMOReservations(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          reservations,
+		})
+	}
+}
+
+type ConsumeRequest struct {
+	ComponentID int     `json:"component_id"`
+	Quantity    float64 `json:"quantity"`
+	// LotNumber and SerialNumber pick the stock of a tracked component;
+	// without them the oldest lots are used.
+	LotNumber    string `json:"lot_number,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+}
+
+// ConsumeMaterialHandler issues components to a running order of a product
+// in manual consumption mode.
+func ConsumeMaterialHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req ConsumeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.ComponentID == 0 || req.Quantity <= 0 {
+			resp := response.GeneralError(fmt.Errorf("component_id and a positive quantity are required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		movements, err := storage.ConsumeMaterial(id, req.ComponentID, req.Quantity, req.LotNumber, req.SerialNumber)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          movements,
+		})
+	}
+}
+
+func GetMovementsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		movements, err := storage.GetMOMovements(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          movements,
+		})
+	}
+}
+
+type QuantityRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+type ProduceRequest struct {
+	Quantity int `json:"quantity"`
+	types.ProductionOutput
+}
+
+// ProduceHandler records a partial completion of a running order.
+func ProduceHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req ProduceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.Quantity <= 0 {
+			resp := response.GeneralError(fmt.Errorf("quantity must be greater than 0"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mo, err := storage.ProduceMO(id, req.Quantity, req.ProductionOutput)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          mo,
+		})
+	}
+}
+
+type CompleteRequest struct {
+	// CloseShort closes the order at the quantity produced so far.
+	CloseShort bool `json:"close_short"`
+	// Backorder raises a new draft order for the quantity left when closing short.
+	Backorder bool `json:"backorder"`
+	// the lot or serials of the remaining quantity posted at completion
+	types.ProductionOutput
+}
+
+// CompleteHandler finishes a running order. The body is optional; without
+// it the remaining quantity is posted as produced.
+func CompleteHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		var req CompleteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.Backorder && !req.CloseShort {
+			resp := response.GeneralError(fmt.Errorf("backorder requires close_short"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mo, backorder, err := storage.CompleteMO(id, req.CloseShort, req.Backorder, req.ProductionOutput, actor.ID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data": map[string]interface{}{
+				"order":     mo,
+				"backorder": backorder,
+			},
+		})
+	}
+}
+
+// SplitHandler moves part of a draft order into a new order.
+func SplitHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req QuantityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.Quantity <= 0 {
+			resp := response.GeneralError(fmt.Errorf("quantity must be greater than 0"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		original, split, err := storage.SplitMO(id, req.Quantity)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data": map[string]interface{}{
+				"order": original,
+				"split": split,
+			},
+		})
+	}
+}
+
+// GetChildrenHandler lists the sub-assembly orders raised when an order was confirmed.
+func GetChildrenHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		children, err := storage.GetChildMOs(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          children,
+		})
+	}
+}
+
+type PriorityRequest struct {
+	Priority int `json:"priority"`
+}
+
+// SetPriorityHandler changes the priority of an open order at any status.
+func SetPriorityHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req PriorityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if err := validPriority(req.Priority); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		mo, err := storage.SetMOPriority(id, req.Priority)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          mo,
+		})
+	}
+}
+
+// GetDependenciesHandler returns the finish-to-start links between the work orders of an order.
+func GetDependenciesHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		deps, err := storage.GetMODependencies(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          deps,
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/planning"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type BoMAlternateRequest struct {
+	ComponentID     int     `json:"component_id"`
+	Priority        int     `json:"priority,omitempty"`
+	ConversionRatio float64 `json:"conversion_ratio,omitempty"`
+}
+
+// parseAlternatePath reads /api/products/{id}/bom/{bomId}/alternates[/{altId}]; altID is 0 when absent.
+func parseAlternatePath(r *http.Request) (productID, bomID, altID int, err error) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 7 || pathParts[4] != "bom" || pathParts[6] != "alternates" {
+		return 0, 0, 0, fmt.Errorf("invalid URL")
+	}
+
+	if productID, err = strconv.Atoi(pathParts[3]); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid product ID: %w", err)
+	}
+	if bomID, err = strconv.Atoi(pathParts[5]); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid bom ID: %w", err)
+	}
+	if len(pathParts) > 7 && pathParts[7] != "" {
+		if altID, err = strconv.Atoi(pathParts[7]); err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid alternate ID: %w", err)
+		}
+	}
+	return productID, bomID, altID, nil
+}
+
+func CreateBoMAlternateHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, bomID, _, err := parseAlternatePath(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req BoMAlternateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.ComponentID == 0 {
+			resp := response.GeneralError(fmt.Errorf("component_id is required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.Priority < 0 || req.ConversionRatio < 0 {
+			resp := response.GeneralError(fmt.Errorf("priority and conversion_ratio cannot be negative"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.Priority == 0 {
+			req.Priority = 1
+		}
+		if req.ConversionRatio == 0 {
+			req.ConversionRatio = 1
+		}
+
+		alt, err := storage.CreateBoMAlternate(productID, types.BoMAlternate{
+			BoMID:           bomID,
+			ComponentID:     req.ComponentID,
+			Priority:        req.Priority,
+			ConversionRatio: req.ConversionRatio,
+		})
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          alt,
+		})
+	}
+}
+
+func GetBoMAlternatesHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, bomID, _, err := parseAlternatePath(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		alts, err := storage.GetBoMAlternates(bomID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          alts,
+		})
+	}
+}
+
+func DeleteBoMAlternateHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, bomID, altID, err := parseAlternatePath(r)
+		if err == nil && altID == 0 {
+			err = fmt.Errorf("missing alternate ID")
+		}
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if err := storage.DeleteBoMAlternate(bomID, altID); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusNotFound, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "alternate deleted successfully",
+		})
+	}
+}
+
+// GetAvailabilityHandler checks whether unreserved stock covers building
+// `quantity` units of a product, falling back to approved alternates.
+// URL: /api/products/{id}/availability?quantity=
+func GetAvailabilityHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 5 {
+			resp := response.GeneralError(fmt.Errorf("invalid URL"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		productID, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid product ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		quantity := 1.0
+		if v := r.URL.Query().Get("quantity"); v != "" {
+			if quantity, err = strconv.ParseFloat(v, 64); err != nil || quantity <= 0 {
+				resp := response.GeneralError(fmt.Errorf("quantity must be a positive number"))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+		}
+
+		freeStock := func(id int) (float64, error) { return storage.GetFreeStock(id, 0) }
+		lines, err := planning.Availability(storage, freeStock, productID, quantity)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          lines,
+		})
+	}
+}
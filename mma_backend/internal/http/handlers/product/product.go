@@ -3,7 +3,12 @@ package product
 import (
 	"encoding/json"
 	"fmt"
+	"mma_api/internal/bom"
+	"mma_api/internal/costing"
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/storage"
 	"mma_api/internal/storage/postgres"
+	"mma_api/internal/trace"
 	"mma_api/internal/types"
 	"mma_api/internal/utils/response"
 	"net/http"
@@ -70,6 +75,31 @@ func GetProductByIDHandler(storage *postgres.Postgres) http.HandlerFunc {
 	}
 }
 
+// validateProduct checks a product body and fills in defaults.
+func validateProduct(product *types.Product) error {
+	if product.Name == "" || product.Unit == "" {
+		return fmt.Errorf("name and unit are required")
+	}
+	if product.PurchaseCost < 0 {
+		return fmt.Errorf("purchase_cost cannot be negative")
+	}
+	switch product.ConsumptionMode {
+	case "":
+		product.ConsumptionMode = manufacturing.ConsumptionBackflush
+	case manufacturing.ConsumptionBackflush, manufacturing.ConsumptionManual:
+	default:
+		return fmt.Errorf("consumption_mode must be %q or %q", manufacturing.ConsumptionBackflush, manufacturing.ConsumptionManual)
+	}
+	switch product.Tracking {
+	case "":
+		product.Tracking = trace.TrackingNone
+	case trace.TrackingNone, trace.TrackingLot, trace.TrackingSerial:
+	default:
+		return fmt.Errorf("tracking must be %q, %q or %q", trace.TrackingNone, trace.TrackingLot, trace.TrackingSerial)
+	}
+	return nil
+}
+
 func CreateProductHandler(storage *postgres.Postgres) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -85,13 +115,13 @@ func CreateProductHandler(storage *postgres.Postgres) http.HandlerFunc {
 			return
 		}
 
-		if product.Name == "" || product.Unit == "" {
-			resp := response.GeneralError(fmt.Errorf("name and unit are required"))
+		if err := validateProduct(&product); err != nil {
+			resp := response.GeneralError(err)
 			_ = response.WriteJson(w, http.StatusBadRequest, resp)
 			return
 		}
 
-		newProduct, err := storage.CreateProduct(product.Name, product.Description, product.Category, product.Unit)
+		newProduct, err := storage.CreateProduct(product)
 		if err != nil {
 			resp := response.GeneralError(err)
 			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
@@ -105,10 +135,63 @@ func CreateProductHandler(storage *postgres.Postgres) http.HandlerFunc {
 	}
 }
 
+func UpdateProductHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 4 {
+			resp := response.GeneralError(fmt.Errorf("missing product ID"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid product ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var product types.Product
+		if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if err := validateProduct(&product); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		product.ID = id
+		updated, err := storage.UpdateProduct(product)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          updated,
+		})
+	}
+}
+
 type BoMCreateRequest struct {
 	ComponentID   int     `json:"component_id"`
 	Quantity      float64 `json:"quantity"`
 	OperationName string  `json:"operation_name,omitempty"`
+	OperationID   *int    `json:"operation_id,omitempty"`
+	// WorkCenterID and the minutes define operation_name for products
+	// without a routing.
+	WorkCenterID      *int    `json:"work_center_id,omitempty"`
+	SetupMinutes      float64 `json:"setup_minutes,omitempty"`
+	RunMinutesPerUnit float64 `json:"run_minutes_per_unit,omitempty"`
+	Version           int     `json:"version,omitempty"`
+	ScrapPercent      float64 `json:"scrap_percent,omitempty"`
+	Phantom           bool    `json:"phantom,omitempty"`
 }
 
 func CreateBoMHandler(storage *postgres.Postgres) http.HandlerFunc {
@@ -136,11 +219,7 @@ func CreateBoMHandler(storage *postgres.Postgres) http.HandlerFunc {
 		}
 
 		// Decode request body
-		var req struct {
-			ComponentID   int     `json:"component_id"`
-			Quantity      float64 `json:"quantity"`
-			OperationName string  `json:"operation_name,omitempty"`
-		}
+		var req BoMCreateRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			resp := response.GeneralError(err)
@@ -153,12 +232,39 @@ func CreateBoMHandler(storage *postgres.Postgres) http.HandlerFunc {
 			_ = response.WriteJson(w, http.StatusBadRequest, resp)
 			return
 		}
+		if req.Version < 0 {
+			resp := response.GeneralError(fmt.Errorf("version must be positive"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.SetupMinutes < 0 || req.RunMinutesPerUnit < 0 {
+			resp := response.GeneralError(fmt.Errorf("setup_minutes and run_minutes_per_unit cannot be negative"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.ScrapPercent < 0 || req.ScrapPercent >= 100 {
+			resp := response.GeneralError(fmt.Errorf("scrap_percent must be between 0 and 100"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
 
 		// Create BoM entry
-		bom, err := storage.CreateBoM(productID, req.ComponentID, req.Quantity, req.OperationName)
+		bom, err := storage.CreateBoM(types.BoM{
+			ProductID:         productID,
+			ComponentID:       req.ComponentID,
+			Quantity:          req.Quantity,
+			OperationName:     req.OperationName,
+			OperationID:       req.OperationID,
+			WorkCenterID:      req.WorkCenterID,
+			SetupMinutes:      req.SetupMinutes,
+			RunMinutesPerUnit: req.RunMinutesPerUnit,
+			Version:           req.Version,
+			ScrapPercent:      req.ScrapPercent,
+			Phantom:           req.Phantom,
+		})
 		if err != nil {
 			resp := response.GeneralError(err)
-			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
 			return
 		}
 
@@ -193,11 +299,21 @@ func GetBoMHandler(storage *postgres.Postgres) http.HandlerFunc {
 			return
 		}
 
+		version := 0
+		if v := r.URL.Query().Get("version"); v != "" {
+			version, err = strconv.Atoi(v)
+			if err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid version: %w", err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+		}
+
 		// Fetch BoM entries from DB
-		boms, err := storage.GetBoM(productID)
+		boms, err := storage.GetBoMVersion(productID, version)
 		if err != nil {
 			resp := response.GeneralError(err)
-			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
 			return
 		}
 
@@ -208,3 +324,281 @@ func GetBoMHandler(storage *postgres.Postgres) http.HandlerFunc {
 		})
 	}
 }
+
+type BoMYieldRequest struct {
+	Version      int     `json:"version,omitempty"`
+	YieldPercent float64 `json:"yield_percent"`
+}
+
+// SetBoMYieldHandler sets the output yield of a BoM version (latest when version is omitted).
+func SetBoMYieldHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/products/{id}/bom/yield
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 6 {
+			resp := response.GeneralError(fmt.Errorf("invalid URL"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		productID, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid product ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req BoMYieldRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.YieldPercent <= 0 || req.YieldPercent > 100 {
+			resp := response.GeneralError(fmt.Errorf("yield_percent must be greater than 0 and at most 100"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if err := storage.SetBoMYield(productID, req.Version, req.YieldPercent); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "bom yield updated",
+		})
+	}
+}
+
+// ExplodeBoMHandler returns the multi-level BoM of a product and the gross
+// requirements for building `quantity` units of it.
+// URL: /api/products/{id}/bom/explode?version=&quantity=
+func ExplodeBoMHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 6 {
+			resp := response.GeneralError(fmt.Errorf("invalid URL"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		productID, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid product ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		version := 0
+		if v := r.URL.Query().Get("version"); v != "" {
+			if version, err = strconv.Atoi(v); err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid version: %w", err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+		}
+		quantity := 1.0
+		if v := r.URL.Query().Get("quantity"); v != "" {
+			if quantity, err = strconv.ParseFloat(v, 64); err != nil || quantity <= 0 {
+				resp := response.GeneralError(fmt.Errorf("quantity must be a positive number"))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+		}
+
+		tree, err := bom.Explode(storage, productID, version)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		requirements := tree.Requirements()
+		for i := range requirements {
+			requirements[i].Quantity *= quantity
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data": map[string]interface{}{
+				"tree":         tree,
+				"quantity":     quantity,
+				"requirements": requirements,
+			},
+		})
+	}
+}
+
+// DiffBoMHandler compares two exploded BoMs.
+// URL: /api/boms/diff?from_product=1&to_product=1&from_version=1&to_version=2&format=json|text|markdown
+// to_product defaults to from_product, versions default to the latest.
+func DiffBoMHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		fromProduct, err := strconv.Atoi(q.Get("from_product"))
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid from_product: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		toProduct := fromProduct
+		fromVersion, toVersion := 0, 0
+		for key, dst := range map[string]*int{"to_product": &toProduct, "from_version": &fromVersion, "to_version": &toVersion} {
+			v := q.Get(key)
+			if v == "" {
+				continue
+			}
+			if *dst, err = strconv.Atoi(v); err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid %s: %w", key, err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+		}
+
+		from, err := bom.Explode(store, fromProduct, fromVersion)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+		to, err := bom.Explode(store, toProduct, toVersion)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		diff := bom.Compare(from, to)
+		switch q.Get("format") {
+		case "", "json":
+			_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+				"custom_status": response.Status_Ok,
+				"data":          diff,
+			})
+		case "text":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte(diff.Text()))
+		case "markdown", "md":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = w.Write([]byte(diff.Markdown()))
+		default:
+			resp := response.GeneralError(fmt.Errorf("unknown format %q", q.Get("format")))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+		}
+	}
+}
+
+// parseCostRequest reads the product id from /api/products/{id}/cost[/...]
+// and the optional lot_size query parameter.
+func parseCostRequest(r *http.Request) (int, float64, error) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 5 || pathParts[4] != "cost" {
+		return 0, 0, fmt.Errorf("invalid URL")
+	}
+
+	productID, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	lotSize := 1.0
+	if v := r.URL.Query().Get("lot_size"); v != "" {
+		lotSize, err = strconv.ParseFloat(v, 64)
+		if err != nil || lotSize <= 0 {
+			return 0, 0, fmt.Errorf("lot_size must be a positive number")
+		}
+	}
+	return productID, lotSize, nil
+}
+
+// GetProductCostHandler rolls up the current standard cost without storing it.
+func GetProductCostHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, lotSize, err := parseCostRequest(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		rollup, err := costing.Roll(storage, productID, lotSize)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          rollup,
+		})
+	}
+}
+
+// RollProductCostHandler re-rolls the standard cost and records it in the cost history.
+func RollProductCostHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, lotSize, err := parseCostRequest(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		rollup, err := costing.Roll(storage, productID, lotSize)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		saved, err := storage.SaveProductCost(types.ProductCost{
+			ProductID:    productID,
+			LotSize:      rollup.LotSize,
+			MaterialCost: rollup.MaterialCost,
+			LabourCost:   rollup.LabourCost,
+			OverheadCost: rollup.OverheadCost,
+			TotalCost:    rollup.TotalCost,
+		})
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data": map[string]interface{}{
+				"cost":   saved,
+				"rollup": rollup,
+			},
+		})
+	}
+}
+
+func GetProductCostHistoryHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, _, err := parseCostRequest(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		history, err := storage.GetProductCostHistory(productID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          history,
+		})
+	}
+}
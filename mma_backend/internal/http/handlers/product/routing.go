@@ -0,0 +1,194 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type RoutingOperationRequest struct {
+	Sequence          int     `json:"sequence,omitempty"`
+	Name              string  `json:"name"`
+	WorkCenterID      int     `json:"work_center_id"`
+	SetupMinutes      float64 `json:"setup_minutes"`
+	RunMinutesPerUnit float64 `json:"run_minutes_per_unit"`
+	Instructions      string  `json:"instructions,omitempty"`
+}
+
+func (req RoutingOperationRequest) validate() error {
+	if req.Name == "" || req.WorkCenterID == 0 {
+		return fmt.Errorf("name and work_center_id are required")
+	}
+	if req.Sequence < 0 || req.SetupMinutes < 0 || req.RunMinutesPerUnit < 0 {
+		return fmt.Errorf("sequence and times cannot be negative")
+	}
+	return nil
+}
+
+// parseRoutingPath reads /api/products/{id}/routing[/{opId}]; opID is 0 when absent.
+func parseRoutingPath(r *http.Request) (productID, opID int, err error) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 5 || pathParts[4] != "routing" {
+		return 0, 0, fmt.Errorf("invalid URL")
+	}
+
+	productID, err = strconv.Atoi(pathParts[3])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid product ID: %w", err)
+	}
+	if len(pathParts) > 5 && pathParts[5] != "" {
+		opID, err = strconv.Atoi(pathParts[5])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid operation ID: %w", err)
+		}
+	}
+	return productID, opID, nil
+}
+
+func CreateRoutingOperationHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, _, err := parseRoutingPath(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req RoutingOperationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if err := req.validate(); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		op, err := storage.CreateRoutingOperation(types.RoutingOperation{
+			ProductID:         productID,
+			Sequence:          req.Sequence,
+			Name:              req.Name,
+			WorkCenterID:      req.WorkCenterID,
+			SetupMinutes:      req.SetupMinutes,
+			RunMinutesPerUnit: req.RunMinutesPerUnit,
+			Instructions:      req.Instructions,
+		})
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          op,
+		})
+	}
+}
+
+func GetRoutingHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, _, err := parseRoutingPath(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		ops, err := storage.GetRouting(productID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          ops,
+		})
+	}
+}
+
+func UpdateRoutingOperationHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, opID, err := parseRoutingPath(r)
+		if err == nil && opID == 0 {
+			err = fmt.Errorf("missing operation ID")
+		}
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var req RoutingOperationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if err := req.validate(); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.Sequence == 0 {
+			resp := response.GeneralError(fmt.Errorf("sequence is required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		op, err := storage.UpdateRoutingOperation(types.RoutingOperation{
+			ID:                opID,
+			ProductID:         productID,
+			Sequence:          req.Sequence,
+			Name:              req.Name,
+			WorkCenterID:      req.WorkCenterID,
+			SetupMinutes:      req.SetupMinutes,
+			RunMinutesPerUnit: req.RunMinutesPerUnit,
+			Instructions:      req.Instructions,
+		})
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          op,
+		})
+	}
+}
+
+func DeleteRoutingOperationHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, opID, err := parseRoutingPath(r)
+		if err == nil && opID == 0 {
+			err = fmt.Errorf("missing operation ID")
+		}
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if err := storage.DeleteRoutingOperation(productID, opID); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "routing operation deleted successfully",
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package scrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ReasonRequest struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	// Active defaults to true.
+	Active *bool `json:"active,omitempty"`
+}
+
+func (req ReasonRequest) toReason() (types.ScrapReason, error) {
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return types.ScrapReason{}, fmt.Errorf("code is required")
+	}
+	reason := types.ScrapReason{Code: code, Description: req.Description, Active: true}
+	if req.Active != nil {
+		reason.Active = *req.Active
+	}
+	return reason, nil
+}
+
+func CreateReasonHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+		if !auth.IsManager(actor) {
+			resp := response.GeneralError(fmt.Errorf("only managers can maintain scrap reasons"))
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		var req ReasonRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		reason, err := req.toReason()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		created, err := storage.CreateScrapReason(reason)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+func UpdateReasonHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/scrap-reasons/{id}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 4 {
+			resp := response.GeneralError(fmt.Errorf("missing scrap reason ID"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid scrap reason ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+		if !auth.IsManager(actor) {
+			resp := response.GeneralError(fmt.Errorf("only managers can maintain scrap reasons"))
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		var req ReasonRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		reason, err := req.toReason()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		reason.ID = id
+
+		updated, err := storage.UpdateScrapReason(reason)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          updated,
+		})
+	}
+}
+
+func GetReasonsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reasons, err := storage.GetScrapReasons()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          reasons,
+		})
+	}
+}
+
+// parseBound reads a from/to query parameter. A plain date as the upper
+// bound covers the whole day.
+func parseBound(v string, upper bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		if upper {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ReportHandler totals scrap and rework, grouped by product (the default),
+// work_center or reason.
+func ReportHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		groupBy := q.Get("group_by")
+		if groupBy == "" {
+			groupBy = "product"
+		}
+		if groupBy != "product" && groupBy != "work_center" && groupBy != "reason" {
+			resp := response.GeneralError(fmt.Errorf("group_by must be product, work_center or reason"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		from, err := parseBound(q.Get("from"), false)
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid from: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		to, err := parseBound(q.Get("to"), true)
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid to: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		lines, err := storage.GetScrapReport(groupBy, from, to)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          lines,
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pathID returns the numeric path segment at index i, e.g. 3 for the id in
+// /api/skills/{id}.
+func pathID(r *http.Request, i int, what string) (int, error) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) <= i {
+		return 0, fmt.Errorf("missing %s ID", what)
+	}
+
+	id, err := strconv.Atoi(pathParts[i])
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s ID: %w", what, err)
+	}
+	return id, nil
+}
+
+type SkillRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+func CreateSkillHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RequireManager(storage, w, r, "change skills and certifications") {
+			return
+		}
+
+		var req SkillRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		req.Code = strings.TrimSpace(req.Code)
+		if req.Code == "" || req.Name == "" {
+			resp := response.GeneralError(fmt.Errorf("code and name are required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		created, err := storage.CreateSkill(types.Skill{Code: req.Code, Name: req.Name, Description: req.Description})
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+func GetSkillsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		skills, err := storage.GetSkills()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          skills,
+		})
+	}
+}
+
+func DeleteSkillHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/skills/{id}
+		id, err := pathID(r, 3, "skill")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change skills and certifications") {
+			return
+		}
+
+		if err := storage.DeleteSkill(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "skill removed successfully",
+		})
+	}
+}
+
+// CertificationRequest certifies a worker for a skill. Dates are plain
+// dates; without expires_at the certification does not lapse.
+type CertificationRequest struct {
+	SkillID     int    `json:"skill_id"`
+	CertifiedAt string `json:"certified_at,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+}
+
+func parseOptionalDate(v, field string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return &t, nil
+}
+
+func SetUserSkillHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/users/{id}/skills
+		userID, err := pathID(r, 3, "user")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change skills and certifications") {
+			return
+		}
+
+		var req CertificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.SkillID == 0 {
+			resp := response.GeneralError(fmt.Errorf("skill_id is required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		us := types.UserSkill{UserID: userID, SkillID: req.SkillID, Reference: req.Reference}
+		if us.CertifiedAt, err = parseOptionalDate(req.CertifiedAt, "certified_at"); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if us.ExpiresAt, err = parseOptionalDate(req.ExpiresAt, "expires_at"); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if us.CertifiedAt != nil && us.ExpiresAt != nil && us.ExpiresAt.Before(*us.CertifiedAt) {
+			resp := response.GeneralError(fmt.Errorf("expires_at must not be before certified_at"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		saved, err := storage.SetUserSkill(us)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          saved,
+		})
+	}
+}
+
+func GetUserSkillsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := pathID(r, 3, "user")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		skills, err := storage.GetUserSkills(userID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          skills,
+		})
+	}
+}
+
+func DeleteUserSkillHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/users/{id}/skills/{skillId}
+		userID, err := pathID(r, 3, "user")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		skillID, err := pathID(r, 5, "skill")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change skills and certifications") {
+			return
+		}
+
+		if err := storage.DeleteUserSkill(userID, skillID); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "certification removed successfully",
+		})
+	}
+}
+
+// RequirementsRequest replaces the full set of skills a work center or
+// operation requires; an empty list clears it.
+type RequirementsRequest struct {
+	SkillIDs []int `json:"skill_ids"`
+}
+
+// SetWorkCenterSkillsHandler replaces the skills required at a work center,
+// PUT /api/work-centers/{id}/skills.
+func SetWorkCenterSkillsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r, 3, "work center")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		setRequirements(storage, w, r, func(skillIDs []int) ([]types.Skill, error) {
+			return storage.SetWorkCenterSkills(id, skillIDs)
+		})
+	}
+}
+
+func GetWorkCenterSkillsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r, 3, "work center")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		skills, err := storage.GetWorkCenterSkills(id)
+		writeRequirements(w, skills, err)
+	}
+}
+
+// SetOperationSkillsHandler replaces the skills required for a routing
+// operation, PUT /api/products/{id}/routing/{opId}/skills.
+func SetOperationSkillsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, opID, err := operationPath(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		setRequirements(storage, w, r, func(skillIDs []int) ([]types.Skill, error) {
+			return storage.SetOperationSkills(productID, opID, skillIDs)
+		})
+	}
+}
+
+func GetOperationSkillsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, opID, err := operationPath(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		skills, err := storage.GetOperationSkills(productID, opID)
+		writeRequirements(w, skills, err)
+	}
+}
+
+// operationPath reads /api/products/{id}/routing/{opId}/skills.
+func operationPath(r *http.Request) (productID, opID int, err error) {
+	if productID, err = pathID(r, 3, "product"); err != nil {
+		return 0, 0, err
+	}
+	if opID, err = pathID(r, 5, "operation"); err != nil {
+		return 0, 0, err
+	}
+	return productID, opID, nil
+}
+
+// setRequirements decodes the skill ids of a manager's PUT and stores them with set.
+func setRequirements(storage storage.Storage, w http.ResponseWriter, r *http.Request, set func([]int) ([]types.Skill, error)) {
+	if !auth.RequireManager(storage, w, r, "change skills and certifications") {
+		return
+	}
+
+	var req RequirementsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.GeneralError(err)
+		_ = response.WriteJson(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	skills, err := set(req.SkillIDs)
+	writeRequirements(w, skills, err)
+}
+
+func writeRequirements(w http.ResponseWriter, skills []types.Skill, err error) {
+	if err != nil {
+		resp := response.GeneralError(err)
+		_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+		return
+	}
+
+	_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"custom_status": response.Status_Ok,
+		"data":          skills,
+	})
+}
+
+// ExpiringHandler lists certifications lapsing within ?days= days (default 30).
+func ExpiringHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		days := 30
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				resp := response.GeneralError(fmt.Errorf("days must be a non-negative number"))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+			days = n
+		}
+
+		certs, err := storage.GetExpiringCertifications(days)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          certs,
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package timesheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timesheet is a worker's booked labour between two dates.
+type Timesheet struct {
+	WorkerID     int                   `json:"worker_id"`
+	Period       string                `json:"period"`
+	From         string                `json:"from"`
+	To           string                `json:"to"`
+	TotalMinutes float64               `json:"total_minutes"`
+	Lines        []types.TimesheetLine `json:"lines"`
+}
+
+// weekStart returns the Monday of the week t falls in.
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// Handler returns a worker's timesheet. With self set the worker is the
+// caller (/api/me/timesheet); otherwise it is taken from the path
+// (/api/users/{id}/timesheet) and only managers may read other workers'
+// timesheets.
+//
+// Query: period=day|week (default day), from and to as inclusive dates
+// (default the current week), format=json|csv.
+func Handler(storage storage.Storage, self bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		workerID := actor.ID
+		if !self {
+			pathParts := strings.Split(r.URL.Path, "/")
+			if len(pathParts) < 4 {
+				resp := response.GeneralError(fmt.Errorf("missing user ID"))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+			workerID, err = strconv.Atoi(pathParts[3])
+			if err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid user ID: %w", err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+			if workerID != actor.ID && !auth.IsManager(actor) {
+				resp := response.GeneralError(fmt.Errorf("only managers can read other workers' timesheets"))
+				_ = response.WriteJson(w, http.StatusForbidden, resp)
+				return
+			}
+		}
+
+		q := r.URL.Query()
+		period := q.Get("period")
+		if period == "" {
+			period = "day"
+		}
+		if period != "day" && period != "week" {
+			resp := response.GeneralError(fmt.Errorf("period must be day or week"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		from := weekStart(time.Now())
+		to := from.AddDate(0, 0, 6)
+		if v := q.Get("from"); v != "" {
+			if from, err = time.Parse(time.DateOnly, v); err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid from: %w", err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+		}
+		if v := q.Get("to"); v != "" {
+			if to, err = time.Parse(time.DateOnly, v); err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid to: %w", err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+		}
+		if to.Before(from) {
+			resp := response.GeneralError(fmt.Errorf("to must not be before from"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		lines, err := storage.GetTimesheet(workerID, period, from, to.AddDate(0, 0, 1))
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		sheet := Timesheet{
+			WorkerID: workerID,
+			Period:   period,
+			From:     from.Format(time.DateOnly),
+			To:       to.Format(time.DateOnly),
+			Lines:    lines,
+		}
+		for _, l := range lines {
+			sheet.TotalMinutes += l.Minutes
+		}
+
+		switch q.Get("format") {
+		case "", "json":
+			_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+				"custom_status": response.Status_Ok,
+				"data":          sheet,
+			})
+		case "csv":
+			writeCSV(w, sheet)
+		default:
+			resp := response.GeneralError(fmt.Errorf("unknown format %q", q.Get("format")))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+		}
+	}
+}
+
+func writeCSV(w http.ResponseWriter, sheet Timesheet) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="timesheet-%d-%s-%s.csv"`, sheet.WorkerID, sheet.From, sheet.To))
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"worker_id", sheet.Period, "work_order_id", "mo_id", "step_name", "product", "entries", "minutes", "hours"})
+	for _, l := range sheet.Lines {
+		_ = cw.Write([]string{
+			strconv.Itoa(sheet.WorkerID),
+			l.Period.Format(time.DateOnly),
+			strconv.Itoa(l.WorkOrderID),
+			strconv.Itoa(l.MOID),
+			l.StepName,
+			l.ProductName,
+			strconv.Itoa(l.Entries),
+			strconv.FormatFloat(l.Minutes, 'f', 2, 64),
+			strconv.FormatFloat(l.Minutes/60, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+}
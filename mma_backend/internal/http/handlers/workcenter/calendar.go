@@ -0,0 +1,445 @@
+package workcenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/calendar"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ShiftPatternRequest struct {
+	Name      string        `json:"name"`
+	IsDefault bool          `json:"is_default"`
+	Shifts    []types.Shift `json:"shifts"`
+}
+
+func (req ShiftPatternRequest) toPattern() (types.ShiftPattern, error) {
+	sp := types.ShiftPattern{Name: strings.TrimSpace(req.Name), IsDefault: req.IsDefault}
+	if sp.Name == "" {
+		return sp, fmt.Errorf("name is required")
+	}
+	for i, s := range req.Shifts {
+		if s.Weekday < 1 || s.Weekday > 7 {
+			return sp, fmt.Errorf("shift %d: weekday must be between 1 (Monday) and 7 (Sunday)", i+1)
+		}
+		start, err := calendar.Clock(s.Start)
+		if err != nil {
+			return sp, fmt.Errorf("shift %d: %w", i+1, err)
+		}
+		end, err := calendar.Clock(s.End)
+		if err != nil {
+			return sp, fmt.Errorf("shift %d: %w", i+1, err)
+		}
+		if start == end {
+			return sp, fmt.Errorf("shift %d: start and end must differ", i+1)
+		}
+		sp.Shifts = append(sp.Shifts, types.Shift{Name: s.Name, Weekday: s.Weekday, Start: s.Start, End: s.End})
+	}
+	// capacity adds shifts up, so overlapping ones would count twice
+	i, j, clash, err := calendar.Clash(sp.Shifts)
+	if err != nil {
+		return sp, err
+	}
+	if clash {
+		return sp, fmt.Errorf("shifts %d and %d overlap", i+1, j+1)
+	}
+	return sp, nil
+}
+
+func parsePatternID(r *http.Request) (int, error) {
+	// URL: /api/shift-patterns/{id}
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		return 0, fmt.Errorf("missing shift pattern ID")
+	}
+
+	id, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		return 0, fmt.Errorf("invalid shift pattern ID: %w", err)
+	}
+	return id, nil
+}
+
+func CreateShiftPatternHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		var req ShiftPatternRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		sp, err := req.toPattern()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		created, err := storage.CreateShiftPattern(sp)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+func UpdateShiftPatternHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parsePatternID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		var req ShiftPatternRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		sp, err := req.toPattern()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		sp.ID = id
+
+		updated, err := storage.UpdateShiftPattern(sp)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          updated,
+		})
+	}
+}
+
+func GetShiftPatternsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patterns, err := storage.GetShiftPatterns()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          patterns,
+		})
+	}
+}
+
+func GetShiftPatternByIDHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parsePatternID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		sp, err := storage.GetShiftPatternByID(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          sp,
+		})
+	}
+}
+
+func DeleteShiftPatternHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parsePatternID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		if err := storage.DeleteShiftPattern(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "shift pattern removed successfully",
+		})
+	}
+}
+
+// ShiftPatternAssignment puts a work center on a pattern; null returns it
+// to the plant default.
+type ShiftPatternAssignment struct {
+	ShiftPatternID *int `json:"shift_pattern_id"`
+}
+
+func SetShiftPatternHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		var req ShiftPatternAssignment
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wc, err := storage.SetWorkCenterShiftPattern(id, req.ShiftPatternID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wc,
+		})
+	}
+}
+
+// HolidayRequest adds a non-working day, for one work center or, without
+// work_center_id, the whole plant.
+type HolidayRequest struct {
+	Date         string `json:"date"`
+	WorkCenterID *int   `json:"work_center_id,omitempty"`
+	Name         string `json:"name"`
+}
+
+func CreateHolidayHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		var req HolidayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		date, err := time.Parse(time.DateOnly, req.Date)
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid date: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		created, err := storage.CreateHoliday(types.Holiday{Date: date, WorkCenterID: req.WorkCenterID, Name: req.Name})
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+// dateRange reads the from and to query parameters as inclusive dates.
+func dateRange(r *http.Request) (from, to *time.Time, err error) {
+	q := r.URL.Query()
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid from: %w", err)
+		}
+		from = &t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid to: %w", err)
+		}
+		to = &t
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, nil, fmt.Errorf("to must not be before from")
+	}
+	return from, to, nil
+}
+
+// GetHolidaysHandler lists holidays, optionally those that apply to
+// ?work_center_id= and between ?from= and ?to=.
+func GetHolidaysHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := dateRange(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		var workCenterID *int
+		if v := r.URL.Query().Get("work_center_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				resp := response.GeneralError(fmt.Errorf("invalid work_center_id: %w", err))
+				_ = response.WriteJson(w, http.StatusBadRequest, resp)
+				return
+			}
+			workCenterID = &id
+		}
+
+		holidays, err := storage.GetHolidays(workCenterID, from, to)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          holidays,
+		})
+	}
+}
+
+func DeleteHolidayHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/holidays/{id}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 4 {
+			resp := response.GeneralError(fmt.Errorf("missing holiday ID"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid holiday ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		if err := storage.DeleteHoliday(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "holiday removed successfully",
+		})
+	}
+}
+
+// maxCapacityDays bounds the range a capacity request may cover.
+const maxCapacityDays = 366
+
+// GetCapacityHandler computes the available hours of a work center from its
+// shift pattern, holidays and planned maintenance for ?from= to ?to=
+// (inclusive, default the next seven days).
+func GetCapacityHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		fromP, toP, err := dateRange(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		now := time.Now().UTC()
+		from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if fromP != nil {
+			from = *fromP
+		}
+		to := from.AddDate(0, 0, 6)
+		if toP != nil {
+			to = *toP
+		}
+		if to.Before(from) {
+			resp := response.GeneralError(fmt.Errorf("to must not be before from"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if to.Sub(from).Hours()/24 >= maxCapacityDays {
+			resp := response.GeneralError(fmt.Errorf("date range must not exceed %d days", maxCapacityDays))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wc, pattern, holidays, err := storage.GetWorkCenterCalendar(id, from, to)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		maintenance, err := storage.GetMaintenanceWindows(wc.ID, from, to.AddDate(0, 0, 2))
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		capacity, err := calendar.Capacity(wc.ID, pattern, holidays, maintenance, wc.Capacity, from, to)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          capacity,
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package workcenter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mma_api/internal/calendar"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/oee"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DowntimeRequest logs a stoppage. started_at defaults to now and ended_at
+// may be left out while the work center is still down; both are RFC 3339.
+type DowntimeRequest struct {
+	Category  string `json:"category"`
+	StartedAt string `json:"started_at,omitempty"`
+	EndedAt   string `json:"ended_at,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+func parseTimestamp(v, field string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return &t, nil
+}
+
+func CreateDowntimeHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		var req DowntimeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !manufacturing.ValidDowntimeCategory(req.Category) {
+			resp := response.GeneralError(fmt.Errorf("category must be breakdown, changeover or no_material"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		ev := types.DowntimeEvent{WorkCenterID: id, Category: req.Category, StartedAt: time.Now(), Notes: req.Notes, ReportedBy: actor.ID}
+		started, err := parseTimestamp(req.StartedAt, "started_at")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if started != nil {
+			ev.StartedAt = *started
+		}
+		if ev.EndedAt, err = parseTimestamp(req.EndedAt, "ended_at"); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if ev.EndedAt != nil && ev.EndedAt.Before(ev.StartedAt) {
+			resp := response.GeneralError(fmt.Errorf("ended_at must not be before started_at"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		created, err := storage.CreateDowntimeEvent(ev)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+type EndDowntimeRequest struct {
+	EndedAt string `json:"ended_at,omitempty"`
+}
+
+// EndDowntimeHandler closes an open downtime event, now unless the optional
+// body gives ended_at.
+func EndDowntimeHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/work-centers/{id}/downtime/{eventId}/end
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 6 {
+			resp := response.GeneralError(fmt.Errorf("missing downtime event ID"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		eventID, err := strconv.Atoi(pathParts[5])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid downtime event ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		if _, err := auth.CurrentUser(storage, r); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		var req EndDowntimeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		endedAt, err := parseTimestamp(req.EndedAt, "ended_at")
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		ev, err := storage.EndDowntimeEvent(id, eventID, endedAt)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          ev,
+		})
+	}
+}
+
+// GetDowntimeHandler lists a work center's downtime, optionally between the
+// dates ?from= and ?to= (inclusive).
+func GetDowntimeHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		from, to, err := dateRange(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if to != nil {
+			end := to.AddDate(0, 0, 1)
+			to = &end
+		}
+
+		if _, err := storage.GetWorkCenterByID(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+		events, err := storage.GetDowntimeEvents(id, from, to)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          events,
+		})
+	}
+}
+
+// GetOEEHandler reports a work center's OEE per day or shift
+// (?group_by=day|shift) for the dates ?from= to ?to=, inclusive, by default
+// the last seven days.
+func GetOEEHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		groupBy := r.URL.Query().Get("group_by")
+		if groupBy == "" {
+			groupBy = oee.ByDay
+		}
+		if groupBy != oee.ByDay && groupBy != oee.ByShift {
+			resp := response.GeneralError(fmt.Errorf("group_by must be day or shift"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		fromP, toP, err := dateRange(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		now := time.Now().UTC()
+		to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if toP != nil {
+			to = *toP
+		}
+		from := to.AddDate(0, 0, -6)
+		if fromP != nil {
+			from = *fromP
+		}
+		if to.Before(from) {
+			resp := response.GeneralError(fmt.Errorf("to must not be before from"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if to.Sub(from).Hours()/24 >= maxCapacityDays {
+			resp := response.GeneralError(fmt.Errorf("date range must not exceed %d days", maxCapacityDays))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		end := to.AddDate(0, 0, 1)
+
+		_, pattern, holidays, err := storage.GetWorkCenterCalendar(id, from, to)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+		windows, err := calendar.Windows(pattern, holidays, from, to)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+		downtime, err := storage.GetDowntimeEvents(id, &from, &end)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+		runs, err := storage.GetWorkOrderRuns(id, from, end)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+		scrap, err := storage.GetWorkCenterScrap(id, from, end)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		periods, total := oee.Compute(windows, groupBy, from, to, downtime, runs, scrap, time.Now())
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data": types.OEEReport{
+				WorkCenterID: id,
+				From:         from.Format(time.DateOnly),
+				To:           to.Format(time.DateOnly),
+				GroupBy:      groupBy,
+				Periods:      periods,
+				Total:        total,
+			},
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package workcenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/storage"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func parseWorkCenterID(r *http.Request) (int, error) {
+	// URL: /api/work-centers/{id}[/...]
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		return 0, fmt.Errorf("missing work center ID")
+	}
+
+	id, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		return 0, fmt.Errorf("invalid work center ID: %w", err)
+	}
+	return id, nil
+}
+
+type RatesRequest struct {
+	LabourRate   float64 `json:"labour_rate"`
+	OverheadRate float64 `json:"overhead_rate"`
+}
+
+// SetRatesHandler sets the hourly labour and overhead rates a work center's
+// operations are costed at.
+func SetRatesHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		var req RatesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.LabourRate < 0 || req.OverheadRate < 0 {
+			resp := response.GeneralError(fmt.Errorf("rates must not be negative"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wc, err := storage.SetWorkCenterRates(id, req.LabourRate, req.OverheadRate)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wc,
+		})
+	}
+}
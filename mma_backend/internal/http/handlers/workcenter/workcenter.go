@@ -0,0 +1,267 @@
+package workcenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strings"
+)
+
+// GetQueueHandler lists the open work orders of a work center by queue rank,
+// then due date.
+func GetQueueHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		queue, err := storage.GetWorkCenterQueue(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          queue,
+		})
+	}
+}
+
+type WorkCenterRequest struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Capacity int    `json:"capacity,omitempty"`
+	// Status defaults to available and is only read on create.
+	Status       string  `json:"status,omitempty"`
+	LabourRate   float64 `json:"labour_rate"`
+	OverheadRate float64 `json:"overhead_rate"`
+}
+
+func (req WorkCenterRequest) toWorkCenter() (types.WorkCenter, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return types.WorkCenter{}, fmt.Errorf("name is required")
+	}
+	if !manufacturing.ValidWorkCenterType(req.Type) {
+		return types.WorkCenter{}, fmt.Errorf("type must be machine, team or location")
+	}
+	if req.Capacity < 0 {
+		return types.WorkCenter{}, fmt.Errorf("capacity must not be negative")
+	}
+	if req.LabourRate < 0 || req.OverheadRate < 0 {
+		return types.WorkCenter{}, fmt.Errorf("rates must not be negative")
+	}
+	status := req.Status
+	if status == "" {
+		status = manufacturing.WorkCenterAvailable
+	}
+	if !manufacturing.ValidWorkCenterStatus(status) {
+		return types.WorkCenter{}, fmt.Errorf("status must be available, down or maintenance")
+	}
+	return types.WorkCenter{
+		Name:         strings.TrimSpace(req.Name),
+		Type:         req.Type,
+		Capacity:     req.Capacity,
+		Status:       status,
+		LabourRate:   req.LabourRate,
+		OverheadRate: req.OverheadRate,
+	}, nil
+}
+
+func CreateWorkCenterHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		var req WorkCenterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		wc, err := req.toWorkCenter()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		created, err := storage.CreateWorkCenter(wc)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          created,
+		})
+	}
+}
+
+func GetWorkCentersHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		centers, err := storage.GetWorkCenters()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          centers,
+		})
+	}
+}
+
+func GetWorkCenterByIDHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wc, err := storage.GetWorkCenterByID(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wc,
+		})
+	}
+}
+
+func UpdateWorkCenterHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		var req WorkCenterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		wc, err := req.toWorkCenter()
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		wc.ID = id
+
+		updated, err := storage.UpdateWorkCenter(wc)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          updated,
+		})
+	}
+}
+
+type StatusRequest struct {
+	Status string `json:"status"`
+}
+
+// SetStatusHandler marks a work center available, down or under
+// maintenance. Work orders cannot start at a center that is not available.
+func SetStatusHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+		if !auth.IsManager(actor) {
+			resp := response.GeneralError(fmt.Errorf("only managers can change work centers"))
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		var req StatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !manufacturing.ValidWorkCenterStatus(req.Status) {
+			resp := response.GeneralError(fmt.Errorf("status must be available, down or maintenance"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wc, err := storage.SetWorkCenterStatus(id, req.Status, actor.ID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wc,
+		})
+	}
+}
+
+func DeleteWorkCenterHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkCenterID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if !auth.RequireManager(storage, w, r, "change work centers") {
+			return
+		}
+
+		if err := storage.DeleteWorkCenter(id); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "work center removed successfully",
+		})
+	}
+}
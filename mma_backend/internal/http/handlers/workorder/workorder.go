@@ -0,0 +1,499 @@
+package workorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"mma_api/internal/http/handlers/auth"
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/storage"
+	"mma_api/internal/types"
+	"mma_api/internal/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func parseWorkOrderID(r *http.Request) (int, error) {
+	// URL: /api/work-orders/{id}[/...]
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		return 0, fmt.Errorf("missing work order ID")
+	}
+
+	id, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		return 0, fmt.Errorf("invalid work order ID: %w", err)
+	}
+	return id, nil
+}
+
+// ReorderRequest names the neighbour a work order is dropped next to. With
+// neither field set the work order moves to the front of its queue.
+type ReorderRequest struct {
+	AfterID  *int `json:"after_id,omitempty"`
+	BeforeID *int `json:"before_id,omitempty"`
+}
+
+// ReorderHandler moves a work order within its work center queue. Only
+// managers may reorder queues.
+func ReorderHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+		if !auth.IsManager(actor) {
+			resp := response.GeneralError(fmt.Errorf("only managers can reorder work center queues"))
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		var req ReorderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.AfterID != nil && req.BeforeID != nil {
+			resp := response.GeneralError(fmt.Errorf("give either after_id or before_id, not both"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if (req.AfterID != nil && *req.AfterID == id) || (req.BeforeID != nil && *req.BeforeID == id) {
+			resp := response.GeneralError(fmt.Errorf("a work order cannot be placed next to itself"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wo, err := storage.ReorderWorkOrder(id, req.AfterID, req.BeforeID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wo,
+		})
+	}
+}
+
+func GetWorkOrderHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wo, err := storage.GetWorkOrderByID(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wo,
+		})
+	}
+}
+
+// ActionHandler applies one execution action, e.g. POST /api/work-orders/{id}/pause.
+// Workers can only act on their own work orders, managers on any.
+func ActionHandler(storage storage.Storage, action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		wo, err := storage.WorkOrderAction(id, action, actor.ID, auth.IsManager(actor))
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wo,
+		})
+	}
+}
+
+func GetTimeLogsHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		logs, err := storage.GetWorkOrderTimeLogs(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          logs,
+		})
+	}
+}
+
+// GetMyWorkOrdersHandler is the caller's to-do list: their own open work
+// orders and the unassigned ones at work centers they are qualified for.
+func GetMyWorkOrdersHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		me, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		tasks, err := storage.GetWorkerTasks(me.ID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          tasks,
+		})
+	}
+}
+
+type DependencyRequest struct {
+	PredecessorID int `json:"predecessor_id"`
+}
+
+// AddDependencyHandler makes a work order wait for another of the same order.
+func AddDependencyHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+		if !auth.IsManager(actor) {
+			resp := response.GeneralError(fmt.Errorf("only managers can change work order dependencies"))
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		var req DependencyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.PredecessorID == 0 || req.PredecessorID == id {
+			resp := response.GeneralError(fmt.Errorf("predecessor_id must name another work order"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		dep, err := storage.AddDependency(id, req.PredecessorID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          dep,
+		})
+	}
+}
+
+func DeleteDependencyHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// URL: /api/work-orders/{id}/dependencies/{predId}
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 6 {
+			resp := response.GeneralError(fmt.Errorf("missing predecessor ID"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		id, err := strconv.Atoi(pathParts[3])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid work order ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		predID, err := strconv.Atoi(pathParts[5])
+		if err != nil {
+			resp := response.GeneralError(fmt.Errorf("invalid predecessor ID: %w", err))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+		if !auth.IsManager(actor) {
+			resp := response.GeneralError(fmt.Errorf("only managers can change work order dependencies"))
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		if err := storage.DeleteDependency(id, predID); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"message":       "dependency removed successfully",
+		})
+	}
+}
+
+// ScrapRequest reports units rejected at a work order. Reworked units stay
+// in the order and are sent through a rework work order instead of being
+// written off.
+type ScrapRequest struct {
+	ReasonID int    `json:"reason_id"`
+	Quantity int    `json:"quantity"`
+	Rework   bool   `json:"rework"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+func ReportScrapHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		var req ScrapRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.Quantity <= 0 {
+			resp := response.GeneralError(fmt.Errorf("quantity must be greater than 0"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+		if req.ReasonID == 0 {
+			resp := response.GeneralError(fmt.Errorf("reason_id is required"))
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wo, err := storage.GetWorkOrderByID(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+		if !auth.IsManager(actor) && (wo.AssignedWorkerID == nil || *wo.AssignedWorkerID != actor.ID) {
+			resp := response.GeneralError(manufacturing.ErrNotAssigned)
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		record, err := storage.ReportScrap(types.ScrapRecord{
+			WorkOrderID: id,
+			ReasonID:    req.ReasonID,
+			Quantity:    req.Quantity,
+			Rework:      req.Rework,
+			ReportedBy:  actor.ID,
+			Notes:       req.Notes,
+		})
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusCreated, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          record,
+		})
+	}
+}
+
+func GetScrapHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		records, err := storage.GetScrapRecords(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusInternalServerError, resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          records,
+		})
+	}
+}
+
+// ClockHandler clocks the caller in on or out of a work order. Any worker
+// may clock in on a running work order, so several can share one.
+func ClockHandler(storage storage.Storage, in bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+
+		var entry *types.LabourEntry
+		status := http.StatusOK
+		if in {
+			entry, err = storage.ClockIn(id, actor.ID)
+			status = http.StatusCreated
+		} else {
+			entry, err = storage.ClockOut(id, actor.ID)
+		}
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, status, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          entry,
+		})
+	}
+}
+
+func GetLabourHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		labour, err := storage.GetWorkOrderLabour(id)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          labour,
+		})
+	}
+}
+
+// AssignRequest hands a work order to a worker; a null worker_id releases it.
+type AssignRequest struct {
+	WorkerID *int `json:"worker_id"`
+}
+
+func AssignHandler(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseWorkOrderID(r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		actor, err := auth.CurrentUser(storage, r)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusUnauthorized, resp)
+			return
+		}
+		if !auth.IsManager(actor) {
+			resp := response.GeneralError(fmt.Errorf("only managers can assign work orders"))
+			_ = response.WriteJson(w, http.StatusForbidden, resp)
+			return
+		}
+
+		var req AssignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		wo, err := storage.AssignWorkOrder(id, req.WorkerID)
+		if err != nil {
+			resp := response.GeneralError(err)
+			_ = response.WriteJson(w, response.ErrorStatus(err), resp)
+			return
+		}
+
+		_ = response.WriteJson(w, http.StatusOK, map[string]interface{}{
+			"custom_status": response.Status_Ok,
+			"data":          wo,
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package manufacturing
+
+// Dependencies is a finish-to-start graph between the work orders of one
+// order: each work order maps to the work orders that must finish before it
+// can start.
+type Dependencies map[int][]int
+
+// DependsOn reports whether `wo` has to wait for `on`, directly or through
+// other work orders.
+func (d Dependencies) DependsOn(wo, on int) bool {
+	seen := map[int]bool{}
+	stack := []int{wo}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, pred := range d[n] {
+			if pred == on {
+				return true
+			}
+			if !seen[pred] {
+				seen[pred] = true
+				stack = append(stack, pred)
+			}
+		}
+	}
+	return false
+}
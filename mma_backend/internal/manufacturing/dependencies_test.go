@@ -0,0 +1,32 @@
+package manufacturing
+
+import "testing"
+
+func TestDependsOn(t *testing.T) {
+	// Cut (1) comes first; weld (2) and paint (3) both wait for it, and
+	// pack (4) waits for both.
+	deps := Dependencies{2: {1}, 3: {1}, 4: {2, 3}}
+
+	for _, pair := range [][2]int{{2, 1}, {3, 1}, {4, 2}, {4, 1}} {
+		if !deps.DependsOn(pair[0], pair[1]) {
+			t.Errorf("%d does not wait for %d", pair[0], pair[1])
+		}
+	}
+	for _, pair := range [][2]int{{1, 4}, {2, 3}, {3, 2}, {4, 4}, {9, 1}} {
+		if deps.DependsOn(pair[0], pair[1]) {
+			t.Errorf("%d waits for %d", pair[0], pair[1])
+		}
+	}
+}
+
+func TestDependsOnFindsCycles(t *testing.T) {
+	// The check that guards new links asks whether the target already waits
+	// for the source; a loop has to answer that without running forever.
+	deps := Dependencies{5: {6}, 6: {5}, 7: {5}}
+	if !deps.DependsOn(5, 5) || !deps.DependsOn(7, 6) {
+		t.Error("the loop between 5 and 6 was not followed")
+	}
+	if deps.DependsOn(5, 7) {
+		t.Error("5 waits for 7, which only hangs off the loop")
+	}
+}
@@ -0,0 +1,26 @@
+package manufacturing
+
+import "time"
+
+// What brings a maintenance plan due: elapsed days or logged run hours.
+const (
+	TriggerCalendar = "calendar"
+	TriggerRunHours = "run_hours"
+)
+
+const (
+	TaskPlanned    = "planned"
+	TaskInProgress = "in_progress"
+	TaskDone       = "done"
+)
+
+// CalendarDue returns when a calendar plan last serviced at base is due again.
+func CalendarDue(base time.Time, intervalDays int) time.Time {
+	return base.AddDate(0, 0, intervalDays)
+}
+
+// RunHoursDue reports whether a run-hour plan has logged enough run time
+// since it was last serviced to be due.
+func RunHoursDue(runHours, intervalHours float64) bool {
+	return runHours >= intervalHours
+}
@@ -0,0 +1,36 @@
+package manufacturing
+
+// Order priorities, higher is more urgent.
+const (
+	PriorityLow    = 0
+	PriorityNormal = 1
+	PriorityHigh   = 2
+	PriorityUrgent = 3
+)
+
+// RankGap is the spacing given to queue ranks when they are appended or
+// renumbered, leaving room to move work orders between neighbours many times
+// before the queue has to be renumbered.
+const RankGap = 1024.0
+
+// minRankGap is the smallest distance between neighbours a new rank may
+// split; below it the float midpoint stops being reliable.
+const minRankGap = 1e-6
+
+// RankBetween returns a rank that sorts between prev and next, either of
+// which may be nil for the start or end of the queue. ok is false when the
+// neighbours are too close together and the queue needs renumbering first.
+func RankBetween(prev, next *float64) (rank float64, ok bool) {
+	switch {
+	case prev == nil && next == nil:
+		return RankGap, true
+	case prev == nil:
+		return *next - RankGap, true
+	case next == nil:
+		return *prev + RankGap, true
+	}
+	if *next-*prev < minRankGap {
+		return 0, false
+	}
+	return (*prev + *next) / 2, true
+}
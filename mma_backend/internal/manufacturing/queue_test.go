@@ -0,0 +1,42 @@
+package manufacturing
+
+import "testing"
+
+func TestRankBetweenEnds(t *testing.T) {
+	if r, ok := RankBetween(nil, nil); r != RankGap || !ok {
+		t.Errorf("rank in an empty queue = %v, %v; want %v", r, ok, RankGap)
+	}
+
+	first, last := 1024.0, 2048.0
+	if r, ok := RankBetween(nil, &first); r != 0 || !ok {
+		t.Errorf("rank before %v = %v, %v; want 0", first, r, ok)
+	}
+	if r, ok := RankBetween(&last, nil); r != 3072 || !ok {
+		t.Errorf("rank after %v = %v, %v; want 3072", last, r, ok)
+	}
+
+	// Moving to the front keeps going below zero.
+	zero := 0.0
+	if r, ok := RankBetween(nil, &zero); r != -RankGap || !ok {
+		t.Errorf("rank before 0 = %v, %v; want %v", r, ok, -RankGap)
+	}
+}
+
+func TestRankBetweenNeighbours(t *testing.T) {
+	tests := []struct {
+		prev, next float64
+		want       float64
+		ok         bool
+	}{
+		{1024, 2048, 1536, true},
+		{1, 1.5, 1.25, true},
+		{-1024, 0, -512, true},
+		{1, 1 + 1e-7, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := RankBetween(&tt.prev, &tt.next)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RankBetween(%v, %v) = %v, %v; want %v, %v", tt.prev, tt.next, got, ok, tt.want, tt.ok)
+		}
+	}
+}
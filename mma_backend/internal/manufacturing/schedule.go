@@ -0,0 +1,51 @@
+package manufacturing
+
+import (
+	"mma_api/internal/calendar"
+	"mma_api/internal/types"
+	"time"
+)
+
+// Timelines returns the working time of a work center; nil asks for the
+// time of work that has no work center.
+type Timelines func(workCenterID *int) calendar.Timeline
+
+// BackSchedule returns when work has to start so it is finished by due. The
+// steps are placed back to back, last first, in the working time of their
+// work centers, so shifts, holidays and maintenance are left out. ok is
+// false when the timelines run out before all of it fits.
+func BackSchedule(due time.Time, steps []types.WorkStep, timeline Timelines) (time.Time, bool) {
+	at := due
+	for i := len(steps) - 1; i >= 0; i-- {
+		start, ok := timeline(steps[i].WorkCenterID).Start(at, steps[i].Minutes)
+		if !ok {
+			return start, false
+		}
+		at = start
+	}
+	return at, true
+}
+
+// Late reports whether an order is past its due date without being finished.
+func Late(status string, due *time.Time, now time.Time) bool {
+	return due != nil && !Closed(status) && now.After(*due)
+}
+
+// AtRisk reports whether an open order cannot finish its remaining steps by
+// its due date when they are run one after another from now in the working
+// time of their work centers. Orders that are already late are not also at
+// risk.
+func AtRisk(status string, due *time.Time, steps []types.WorkStep, timeline Timelines, now time.Time) bool {
+	if due == nil || Closed(status) || now.After(*due) {
+		return false
+	}
+	at := now
+	for _, s := range steps {
+		finish, ok := timeline(s.WorkCenterID).Finish(at, s.Minutes)
+		if !ok {
+			return true
+		}
+		at = finish
+	}
+	return at.After(*due)
+}
@@ -0,0 +1,120 @@
+package manufacturing
+
+import (
+	"mma_api/internal/calendar"
+	"mma_api/internal/types"
+	"testing"
+	"time"
+)
+
+var (
+	monday   = time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	tuesday  = monday.AddDate(0, 0, 1)
+	thursday = monday.AddDate(0, 0, 3)
+)
+
+// Work centers 1 and 2 both work 08:00 to 16:00 every day; 2 is down for
+// maintenance from 08:00 to 12:00 on Tuesday. Work without a center has
+// no time at all.
+var saw, press = 1, 2
+
+func shopTimelines(t *testing.T) Timelines {
+	windows, err := calendar.Windows(calendar.DayShifts(), nil, monday, thursday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byCenter := map[int]calendar.Timeline{
+		saw:   calendar.NewTimeline(windows, nil),
+		press: calendar.NewTimeline(windows, []types.MaintenanceTask{{DueAt: tuesday.Add(8 * time.Hour), PlannedEnd: tuesday.Add(12 * time.Hour)}}),
+	}
+	return func(workCenterID *int) calendar.Timeline {
+		if workCenterID == nil {
+			return nil
+		}
+		return byCenter[*workCenterID]
+	}
+}
+
+func TestBackSchedule(t *testing.T) {
+	timelines := shopTimelines(t)
+	wednesday := monday.AddDate(0, 0, 2)
+	tests := []struct {
+		name  string
+		due   time.Time
+		steps []types.WorkStep
+		want  time.Time
+		ok    bool
+	}{
+		{"no work", wednesday, nil, wednesday, true},
+		{"in the last shift", wednesday,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 120}}, tuesday.Add(14 * time.Hour), true},
+		{"back to back over days", wednesday,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 300}, {WorkCenterID: &saw, Minutes: 480}}, monday.Add(11 * time.Hour), true},
+		{"around maintenance", tuesday.Add(14 * time.Hour),
+			[]types.WorkStep{{WorkCenterID: &press, Minutes: 180}}, monday.Add(15 * time.Hour), true},
+		{"nowhere to work", wednesday,
+			[]types.WorkStep{{Minutes: 60}}, wednesday, false},
+	}
+	for _, tt := range tests {
+		got, ok := BackSchedule(tt.due, tt.steps, timelines)
+		if !got.Equal(tt.want) || ok != tt.ok {
+			t.Errorf("%s: BackSchedule = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLate(t *testing.T) {
+	due := tuesday
+	afterDue := tuesday.Add(9 * time.Hour)
+	for status, want := range map[string]bool{
+		StatusConfirmed:  true,
+		StatusInProgress: true,
+		StatusDone:       false,
+		StatusCancelled:  false,
+	} {
+		if got := Late(status, &due, afterDue); got != want {
+			t.Errorf("Late(%s) after the due date = %v, want %v", status, got, want)
+		}
+	}
+	if Late(StatusConfirmed, &due, monday.Add(9*time.Hour)) {
+		t.Error("an order is late before its due date")
+	}
+	if Late(StatusInProgress, nil, afterDue) {
+		t.Error("an order without a due date is late")
+	}
+}
+
+func TestAtRisk(t *testing.T) {
+	timelines := shopTimelines(t)
+	due := monday.AddDate(0, 0, 2)
+	mondayMorning := monday.Add(9 * time.Hour)
+	tests := []struct {
+		name   string
+		status string
+		due    *time.Time
+		steps  []types.WorkStep
+		now    time.Time
+		want   bool
+	}{
+		{"fits the shifts left", StatusConfirmed, &due,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 600}}, mondayMorning, false},
+		{"more work than shifts left", StatusConfirmed, &due,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 960}}, mondayMorning, true},
+		{"maintenance takes the time", StatusInProgress, &due,
+			[]types.WorkStep{{WorkCenterID: &press, Minutes: 400}}, tuesday.Add(8 * time.Hour), true},
+		{"steps add up across centers", StatusConfirmed, &due,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 420}, {WorkCenterID: &press, Minutes: 300}}, mondayMorning, true},
+		{"nowhere to work", StatusConfirmed, &due, []types.WorkStep{{Minutes: 10}}, mondayMorning, true},
+		{"already late", StatusConfirmed, &due,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 900}}, due.Add(9 * time.Hour), false},
+		{"done", StatusDone, &due,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 900}}, mondayMorning, false},
+		{"no due date", StatusConfirmed, nil,
+			[]types.WorkStep{{WorkCenterID: &saw, Minutes: 900}}, mondayMorning, false},
+	}
+	for _, tt := range tests {
+		if got := AtRisk(tt.status, tt.due, tt.steps, timelines, tt.now); got != tt.want {
+			t.Errorf("%s: AtRisk = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
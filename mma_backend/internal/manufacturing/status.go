@@ -0,0 +1,100 @@
+package manufacturing
+
+import (
+	"fmt"
+	"mma_api/internal/apperr"
+)
+
+const (
+	StatusDraft      = "draft"
+	StatusConfirmed  = "confirmed"
+	StatusInProgress = "in_progress"
+	StatusOnHold     = "on_hold"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+const (
+	ActionConfirm  = "confirm"
+	ActionStart    = "start"
+	ActionComplete = "complete"
+	ActionHold     = "hold"
+	ActionResume   = "resume"
+	ActionCancel   = "cancel"
+)
+
+// How the components of a product are taken out of stock: automatically when
+// production is reported (backflush) or by explicit consumption postings.
+const (
+	ConsumptionBackflush = "backflush"
+	ConsumptionManual    = "manual"
+)
+
+var (
+	// ErrNotEditable is returned when an order is changed after it left draft.
+	ErrNotEditable = apperr.New(apperr.Conflict, "manufacturing order can no longer be changed")
+	// ErrIllegalTransition is returned for an action the current status does not allow.
+	ErrIllegalTransition = apperr.New(apperr.Conflict, "illegal status transition")
+	// ErrGuardFailed is returned when a transition is legal but its preconditions are not met.
+	ErrGuardFailed = apperr.New(apperr.Conflict, "transition precondition not met")
+)
+
+// transitions maps each action to the statuses it may be taken from and the
+// status it leads to. Resume is absent: it returns to whatever status the
+// order was held from.
+var transitions = map[string]struct {
+	from []string
+	to   string
+}{
+	ActionConfirm:  {from: []string{StatusDraft}, to: StatusConfirmed},
+	ActionStart:    {from: []string{StatusConfirmed}, to: StatusInProgress},
+	ActionComplete: {from: []string{StatusInProgress}, to: StatusDone},
+	ActionHold:     {from: []string{StatusConfirmed, StatusInProgress}, to: StatusOnHold},
+	ActionCancel:   {from: []string{StatusDraft, StatusConfirmed, StatusInProgress, StatusOnHold}, to: StatusCancelled},
+}
+
+// Next returns the status an order in status `from` moves to when `action`
+// is applied. heldFrom is the status an on_hold order was held from and is
+// only consulted for resume.
+func Next(action, from, heldFrom string) (string, error) {
+	if action == ActionResume {
+		if from != StatusOnHold {
+			return "", fmt.Errorf("%w: cannot resume an order that is %s", ErrIllegalTransition, from)
+		}
+		if heldFrom == "" {
+			heldFrom = StatusConfirmed
+		}
+		return heldFrom, nil
+	}
+
+	t, ok := transitions[action]
+	if !ok {
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+	for _, f := range t.from {
+		if f == from {
+			return t.to, nil
+		}
+	}
+	return "", fmt.Errorf("%w: cannot %s an order that is %s", ErrIllegalTransition, action, from)
+}
+
+// FirstEntry reports whether an order moving from `from` into `to` reaches
+// `to` for the first time: confirming a draft or starting a confirmed order,
+// not resuming one that was held from either. What entering a status sets up
+// (work orders and reservations on confirm, the sub-assembly check on start)
+// happens only then.
+func FirstEntry(from, to string) bool {
+	switch to {
+	case StatusConfirmed:
+		return from == StatusDraft
+	case StatusInProgress:
+		return from == StatusConfirmed
+	}
+	return true
+}
+
+// Closed reports whether an order has reached a terminal status.
+func Closed(status string) bool {
+	return status == StatusDone || status == StatusCancelled
+}
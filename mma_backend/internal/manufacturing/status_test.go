@@ -0,0 +1,27 @@
+package manufacturing
+
+import "testing"
+
+func TestHoldAndResume(t *testing.T) {
+	for _, heldFrom := range []string{StatusConfirmed, StatusInProgress} {
+		held, err := Next(ActionHold, heldFrom, "")
+		if err != nil || held != StatusOnHold {
+			t.Fatalf("hold from %s = %q, %v; want on_hold", heldFrom, held, err)
+		}
+		resumed, err := Next(ActionResume, held, heldFrom)
+		if err != nil || resumed != heldFrom {
+			t.Fatalf("resume to %s = %q, %v", heldFrom, resumed, err)
+		}
+		// Coming back from hold must not plan or check the order again.
+		if FirstEntry(held, resumed) {
+			t.Errorf("resuming into %s counts as entering it", heldFrom)
+		}
+	}
+
+	if !FirstEntry(StatusDraft, StatusConfirmed) || !FirstEntry(StatusConfirmed, StatusInProgress) {
+		t.Error("confirming a draft or starting a confirmed order does not count as entering")
+	}
+	if !FirstEntry(StatusOnHold, StatusCancelled) {
+		t.Error("cancelling a held order does not count as entering cancelled")
+	}
+}
@@ -0,0 +1,38 @@
+package manufacturing
+
+// Work center types as constrained by the work_centers table.
+const (
+	WorkCenterMachine  = "machine"
+	WorkCenterTeam     = "team"
+	WorkCenterLocation = "location"
+)
+
+// Operational status of a work center. Work can only start at an
+// available one.
+const (
+	WorkCenterAvailable   = "available"
+	WorkCenterDown        = "down"
+	WorkCenterMaintenance = "maintenance"
+)
+
+// Downtime categories: why a work center stood still.
+const (
+	DowntimeBreakdown  = "breakdown"
+	DowntimeChangeover = "changeover"
+	DowntimeNoMaterial = "no_material"
+)
+
+// ValidWorkCenterType reports whether t is a known work center type.
+func ValidWorkCenterType(t string) bool {
+	return t == WorkCenterMachine || t == WorkCenterTeam || t == WorkCenterLocation
+}
+
+// ValidWorkCenterStatus reports whether s is a known operational status.
+func ValidWorkCenterStatus(s string) bool {
+	return s == WorkCenterAvailable || s == WorkCenterDown || s == WorkCenterMaintenance
+}
+
+// ValidDowntimeCategory reports whether c is a known downtime category.
+func ValidDowntimeCategory(c string) bool {
+	return c == DowntimeBreakdown || c == DowntimeChangeover || c == DowntimeNoMaterial
+}
@@ -0,0 +1,54 @@
+package manufacturing
+
+import (
+	"fmt"
+	"mma_api/internal/apperr"
+)
+
+const (
+	WOPending    = "pending"
+	WOInProgress = "in_progress"
+	WOPaused     = "paused"
+	WOCompleted  = "completed"
+)
+
+const (
+	WOActionStart    = "start"
+	WOActionPause    = "pause"
+	WOActionResume   = "resume"
+	WOActionComplete = "complete"
+)
+
+// ErrNotAssigned is returned when a worker acts on a work order assigned to
+// someone else.
+var ErrNotAssigned = apperr.New(apperr.Forbidden, "work order is assigned to another worker")
+
+// ErrNotCertified is returned when a worker lacks a valid certification for
+// a skill the work center or operation requires, or takes work at a center
+// they are not qualified for.
+var ErrNotCertified = apperr.New(apperr.Forbidden, "worker is not certified for this work")
+
+var woTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	WOActionStart:    {from: []string{WOPending}, to: WOInProgress},
+	WOActionPause:    {from: []string{WOInProgress}, to: WOPaused},
+	WOActionResume:   {from: []string{WOPaused}, to: WOInProgress},
+	WOActionComplete: {from: []string{WOInProgress, WOPaused}, to: WOCompleted},
+}
+
+// NextWO returns the status a work order in status `from` moves to when
+// `action` is applied.
+func NextWO(action, from string) (string, error) {
+	t, ok := woTransitions[action]
+	if !ok {
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+	for _, f := range t.from {
+		if f == from {
+			return t.to, nil
+		}
+	}
+	return "", fmt.Errorf("%w: cannot %s a work order that is %s", ErrIllegalTransition, action, from)
+}
@@ -0,0 +1,46 @@
+package manufacturing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNextWOLifecycle(t *testing.T) {
+	status := WOPending
+	for _, step := range []struct{ action, want string }{
+		{WOActionStart, WOInProgress},
+		{WOActionPause, WOPaused},
+		{WOActionResume, WOInProgress},
+		{WOActionPause, WOPaused},
+		{WOActionComplete, WOCompleted},
+	} {
+		next, err := NextWO(step.action, status)
+		if err != nil {
+			t.Fatalf("%s from %s: %v", step.action, status, err)
+		}
+		if next != step.want {
+			t.Fatalf("%s from %s = %s, want %s", step.action, status, next, step.want)
+		}
+		status = next
+	}
+}
+
+func TestNextWORejects(t *testing.T) {
+	illegal := map[string][]string{
+		WOActionStart:    {WOInProgress, WOPaused, WOCompleted},
+		WOActionPause:    {WOPending, WOPaused, WOCompleted},
+		WOActionResume:   {WOPending, WOInProgress, WOCompleted},
+		WOActionComplete: {WOPending, WOCompleted},
+	}
+	for action, froms := range illegal {
+		for _, from := range froms {
+			if _, err := NextWO(action, from); !errors.Is(err, ErrIllegalTransition) {
+				t.Errorf("%s from %s: error = %v, want ErrIllegalTransition", action, from, err)
+			}
+		}
+	}
+
+	if _, err := NextWO("restart", WOPending); err == nil || errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("unknown action: error = %v, want it named as unknown", err)
+	}
+}
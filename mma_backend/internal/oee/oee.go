@@ -0,0 +1,155 @@
+package oee
+
+import (
+	"math"
+	"mma_api/internal/calendar"
+	"mma_api/internal/types"
+	"time"
+)
+
+// Grouping of an OEE report.
+const (
+	ByDay   = "day"
+	ByShift = "shift"
+)
+
+// bucket is one period of the report with the planned windows it covers and
+// the span work and scrap are attributed to it by.
+type bucket struct {
+	period  types.OEEPeriod
+	windows []calendar.Window
+}
+
+// Compute works out OEE = availability × performance × quality for each
+// day (from..to inclusive) or each shift window:
+//
+//   - availability is the planned shift time not lost to downtime,
+//   - performance is the standard time of the completed work orders over
+//     the time they actually ran,
+//   - quality is the share of units through the completed work orders that
+//     were not scrapped or sent to rework.
+//
+// Work orders count towards the period they were completed in, and so does
+// the scrap found on them; scrap on a work order outside runs counts where
+// it was reported. Downtime only counts where it overlaps planned shift time;
+// open events run until now.
+func Compute(windows []calendar.Window, groupBy string, from, to time.Time,
+	downtime []types.DowntimeEvent, runs []types.WorkOrderRun, scrap []types.ScrapRecord, now time.Time) ([]types.OEEPeriod, types.OEEPeriod) {
+
+	var buckets []*bucket
+	if groupBy == ByShift {
+		for _, w := range windows {
+			buckets = append(buckets, &bucket{
+				period:  types.OEEPeriod{Date: w.Date.Format(time.DateOnly), Shift: w.Shift, Start: w.Start, End: w.End},
+				windows: []calendar.Window{w},
+			})
+		}
+	} else {
+		byDate := map[string]*bucket{}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			b := &bucket{period: types.OEEPeriod{Date: d.Format(time.DateOnly), Start: d, End: d.AddDate(0, 0, 1)}}
+			byDate[b.period.Date] = b
+			buckets = append(buckets, b)
+		}
+		for _, w := range windows {
+			if b, ok := byDate[w.Date.Format(time.DateOnly)]; ok {
+				b.windows = append(b.windows, w)
+			}
+		}
+	}
+
+	completed := map[int]time.Time{}
+	for _, r := range runs {
+		completed[r.WorkOrderID] = r.EndTime
+	}
+
+	total := types.OEEPeriod{Start: from, End: to.AddDate(0, 0, 1), Downtime: map[string]float64{}}
+	periods := make([]types.OEEPeriod, 0, len(buckets))
+	for _, b := range buckets {
+		p := &b.period
+		p.Downtime = map[string]float64{}
+		for _, w := range b.windows {
+			p.PlannedMinutes += w.End.Sub(w.Start).Minutes()
+			for _, ev := range downtime {
+				end := now
+				if ev.EndedAt != nil {
+					end = *ev.EndedAt
+				}
+				if m := calendar.Overlap(ev.StartedAt, end, w.Start, w.End); m > 0 {
+					p.DowntimeMinutes += m
+					p.Downtime[ev.Category] += m
+				}
+			}
+		}
+		for _, r := range runs {
+			if within(r.EndTime, p.Start, p.End) {
+				p.RunMinutes += r.ActualMinutes
+				p.StandardMinutes += r.PlannedMinutes
+				p.Units += r.Units
+			}
+		}
+		for _, s := range scrap {
+			at, ok := completed[s.WorkOrderID]
+			if !ok {
+				at = s.CreatedAt
+			}
+			if within(at, p.Start, p.End) {
+				p.RejectedUnits += s.Quantity
+			}
+		}
+
+		total.PlannedMinutes += p.PlannedMinutes
+		total.DowntimeMinutes += p.DowntimeMinutes
+		for c, m := range p.Downtime {
+			total.Downtime[c] += m
+		}
+		total.RunMinutes += p.RunMinutes
+		total.StandardMinutes += p.StandardMinutes
+		total.Units += p.Units
+		total.RejectedUnits += p.RejectedUnits
+
+		ratios(p)
+		periods = append(periods, *p)
+	}
+
+	ratios(&total)
+	return periods, total
+}
+
+// ratios fills in the OEE factors of a period from its totals.
+func ratios(p *types.OEEPeriod) {
+	p.PlannedMinutes = round(p.PlannedMinutes, 2)
+	p.DowntimeMinutes = round(p.DowntimeMinutes, 2)
+	p.RunMinutes = round(p.RunMinutes, 2)
+	p.StandardMinutes = round(p.StandardMinutes, 2)
+	for c, m := range p.Downtime {
+		p.Downtime[c] = round(m, 2)
+	}
+
+	if p.PlannedMinutes > 0 {
+		p.Availability = ratio(math.Max(p.PlannedMinutes-p.DowntimeMinutes, 0), p.PlannedMinutes)
+	}
+	if p.RunMinutes > 0 {
+		p.Performance = ratio(p.StandardMinutes, p.RunMinutes)
+	}
+	if p.Units > 0 {
+		p.Quality = ratio(math.Max(float64(p.Units-p.RejectedUnits), 0), float64(p.Units))
+	}
+	if p.Availability != nil && p.Performance != nil && p.Quality != nil {
+		p.OEE = ratio(*p.Availability**p.Performance**p.Quality, 1)
+	}
+}
+
+func ratio(num, den float64) *float64 {
+	v := round(num/den, 4)
+	return &v
+}
+
+func round(v float64, places int) float64 {
+	f := math.Pow(10, float64(places))
+	return math.Round(v*f) / f
+}
+
+func within(t, start, end time.Time) bool {
+	return !t.Before(start) && t.Before(end)
+}
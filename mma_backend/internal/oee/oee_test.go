@@ -0,0 +1,119 @@
+package oee
+
+import (
+	"fmt"
+	"mma_api/internal/calendar"
+	"mma_api/internal/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+// line renders a period the way the assertions below spell it out; factors
+// that cannot be worked out print as "-".
+func line(p types.OEEPeriod) string {
+	factor := func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprint(*v)
+	}
+	label := strings.TrimSpace(p.Date + " " + p.Shift)
+	return strings.TrimSpace(fmt.Sprintf("%s planned=%g down=%g run=%g std=%g units=%d rejected=%d A=%s P=%s Q=%s OEE=%s",
+		label, p.PlannedMinutes, p.DowntimeMinutes, p.RunMinutes, p.StandardMinutes, p.Units, p.RejectedUnits,
+		factor(p.Availability), factor(p.Performance), factor(p.Quality), factor(p.OEE)))
+}
+
+func TestCompute(t *testing.T) {
+	hour := func(day, h int) time.Time { return time.Date(2025, 9, day, h, 0, 0, 0, time.UTC) }
+	// An early and a late shift on the 1st, only an early one on the 2nd.
+	windows := []calendar.Window{
+		{Date: hour(1, 0), Shift: "early", Start: hour(1, 6), End: hour(1, 14)},
+		{Date: hour(1, 0), Shift: "late", Start: hour(1, 14), End: hour(1, 22)},
+		{Date: hour(2, 0), Shift: "early", Start: hour(2, 6), End: hour(2, 14)},
+	}
+	breakdownOver := hour(1, 7)
+
+	tests := []struct {
+		name     string
+		groupBy  string
+		downtime []types.DowntimeEvent
+		runs     []types.WorkOrderRun
+		scrap    []types.ScrapRecord
+		now      time.Time
+		want     []string
+	}{
+		{
+			name:    "idle",
+			groupBy: ByDay,
+			now:     hour(3, 0),
+			want: []string{
+				"2025-09-01 planned=960 down=0 run=0 std=0 units=0 rejected=0 A=1 P=- Q=- OEE=-",
+				"2025-09-02 planned=480 down=0 run=0 std=0 units=0 rejected=0 A=1 P=- Q=- OEE=-",
+				"planned=1440 down=0 run=0 std=0 units=0 rejected=0 A=1 P=- Q=- OEE=-",
+			},
+		},
+		{
+			// The breakdown only costs its hour inside the early shift; the
+			// setup is still going on and runs until now.
+			name:    "downtime",
+			groupBy: ByDay,
+			downtime: []types.DowntimeEvent{
+				{Category: "breakdown", StartedAt: hour(1, 5), EndedAt: &breakdownOver},
+				{Category: "setup", StartedAt: hour(2, 13)},
+			},
+			now: hour(2, 20),
+			want: []string{
+				"2025-09-01 planned=960 down=60 run=0 std=0 units=0 rejected=0 A=0.9375 P=- Q=- OEE=-",
+				"2025-09-02 planned=480 down=60 run=0 std=0 units=0 rejected=0 A=0.875 P=- Q=- OEE=-",
+				"planned=1440 down=120 run=0 std=0 units=0 rejected=0 A=0.9167 P=- Q=- OEE=-",
+			},
+		},
+		{
+			// Scrap found on the 2nd belongs to the run completed on the 1st;
+			// scrap on a work order that did not run here stays on its day.
+			name:    "scrap",
+			groupBy: ByDay,
+			runs: []types.WorkOrderRun{
+				{WorkOrderID: 1, EndTime: hour(1, 12), PlannedMinutes: 90, ActualMinutes: 100, Units: 10},
+			},
+			scrap: []types.ScrapRecord{
+				{WorkOrderID: 1, Quantity: 2, CreatedAt: hour(2, 8)},
+				{WorkOrderID: 9, Quantity: 1, CreatedAt: hour(2, 9)},
+			},
+			now: hour(3, 0),
+			want: []string{
+				"2025-09-01 planned=960 down=0 run=100 std=90 units=10 rejected=2 A=1 P=0.9 Q=0.8 OEE=0.72",
+				"2025-09-02 planned=480 down=0 run=0 std=0 units=0 rejected=1 A=1 P=- Q=- OEE=-",
+				"planned=1440 down=0 run=100 std=90 units=10 rejected=3 A=1 P=0.9 Q=0.7 OEE=0.63",
+			},
+		},
+		{
+			name:    "by shift",
+			groupBy: ByShift,
+			runs: []types.WorkOrderRun{
+				{WorkOrderID: 1, EndTime: hour(1, 15), PlannedMinutes: 60, ActualMinutes: 60, Units: 5},
+			},
+			now: hour(3, 0),
+			want: []string{
+				"2025-09-01 early planned=480 down=0 run=0 std=0 units=0 rejected=0 A=1 P=- Q=- OEE=-",
+				"2025-09-01 late planned=480 down=0 run=60 std=60 units=5 rejected=0 A=1 P=1 Q=1 OEE=1",
+				"2025-09-02 early planned=480 down=0 run=0 std=0 units=0 rejected=0 A=1 P=- Q=- OEE=-",
+				"planned=1440 down=0 run=60 std=60 units=5 rejected=0 A=1 P=1 Q=1 OEE=1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods, total := Compute(windows, tt.groupBy, hour(1, 0), hour(2, 0), tt.downtime, tt.runs, tt.scrap, tt.now)
+			var got []string
+			for _, p := range periods {
+				got = append(got, line(p))
+			}
+			got = append(got, line(total))
+			if g, w := strings.Join(got, "\n"), strings.Join(tt.want, "\n"); g != w {
+				t.Errorf("got\n%s\nwant\n%s", g, w)
+			}
+		})
+	}
+}
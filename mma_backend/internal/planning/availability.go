@@ -0,0 +1,95 @@
+package planning
+
+import (
+	"mma_api/internal/bom"
+	"mma_api/internal/types"
+)
+
+type Source interface {
+	bom.Source
+	GetBoMAlternates(bomID int) ([]types.BoMAlternate, error)
+}
+
+// StockFunc returns the quantity of a product that can still be promised.
+type StockFunc func(productID int) (float64, error)
+
+type ComponentAvailability struct {
+	BoMID         int     `json:"bom_id"`
+	ComponentID   int     `json:"component_id"`
+	ComponentName string  `json:"component_name"`
+	Required      float64 `json:"required"`
+	Available     float64 `json:"available"`
+	Shortage      float64 `json:"shortage"`
+	// SubAssembly is set when the component is made in house from its own BoM.
+	SubAssembly bool `json:"sub_assembly"`
+	// Allocations shows how the requirement would be covered, substitutes included.
+	Allocations []bom.Allocation `json:"allocations"`
+}
+
+// Availability checks the gross requirements for quantity units of productID
+// against stock. Lines that share a component draw from the same stock, and a
+// line whose primary component runs short falls back to its alternates.
+func Availability(src Source, stockOf StockFunc, productID int, quantity float64) ([]ComponentAvailability, error) {
+	tree, err := bom.Explode(src, productID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	stock := map[int]float64{}
+	balance := func(id int) (float64, error) {
+		if v, ok := stock[id]; ok {
+			return v, nil
+		}
+		v, err := stockOf(id)
+		if err != nil {
+			return 0, err
+		}
+		stock[id] = v
+		return v, nil
+	}
+
+	var out []ComponentAvailability
+	for _, req := range tree.Requirements() {
+		required := req.Quantity * quantity
+		onHand, err := balance(req.ComponentID)
+		if err != nil {
+			return nil, err
+		}
+
+		alts, err := src.GetBoMAlternates(req.BoMID)
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]bom.Candidate, 0, len(alts))
+		for _, a := range alts {
+			v, err := balance(a.ComponentID)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, bom.Candidate{
+				AlternateID:     a.ID,
+				ComponentID:     a.ComponentID,
+				Priority:        a.Priority,
+				ConversionRatio: a.ConversionRatio,
+				Available:       v,
+			})
+		}
+
+		allocs, shortage := bom.Allocate(required, bom.Candidate{ComponentID: req.ComponentID, Available: onHand}, candidates)
+		for _, a := range allocs {
+			stock[a.ComponentID] -= a.Quantity
+		}
+
+		out = append(out, ComponentAvailability{
+			BoMID:         req.BoMID,
+			ComponentID:   req.ComponentID,
+			ComponentName: req.ComponentName,
+			Required:      required,
+			Available:     onHand,
+			Shortage:      shortage,
+			SubAssembly:   req.SubAssembly,
+			Allocations:   allocs,
+		})
+	}
+	return out, nil
+}
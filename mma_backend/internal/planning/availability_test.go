@@ -0,0 +1,92 @@
+package planning
+
+import (
+	"fmt"
+	"math"
+	"mma_api/internal/types"
+	"testing"
+)
+
+// shelfStore sells a shelf (1) of four brackets (2), eight screws (3) and
+// four more screws for the back panel. The first screw line may fall back
+// on screw strips of ten (5) and then on long screws (4).
+type shelfStore struct {
+	stock map[int]float64
+}
+
+var shelfNames = map[int]string{1: "shelf", 2: "bracket", 3: "screw", 4: "long screw", 5: "screw strip"}
+
+func (s shelfStore) GetBoMVersion(productID, version int) ([]types.BoM, error) {
+	if productID != 1 {
+		return nil, nil
+	}
+	return []types.BoM{
+		{ID: 1, ProductID: 1, ComponentID: 2, Quantity: 4, Version: 1},
+		{ID: 2, ProductID: 1, ComponentID: 3, Quantity: 8, Version: 1},
+		{ID: 3, ProductID: 1, ComponentID: 3, Quantity: 4, Version: 1},
+	}, nil
+}
+
+func (s shelfStore) GetBoMYield(productID, version int) (float64, error) {
+	return 100, nil
+}
+
+func (s shelfStore) GetProductById(id int) (*types.Product, error) {
+	name, ok := shelfNames[id]
+	if !ok {
+		return nil, fmt.Errorf("product with id %d not found", id)
+	}
+	return &types.Product{ID: id, Name: name}, nil
+}
+
+func (s shelfStore) GetBoMAlternates(bomID int) ([]types.BoMAlternate, error) {
+	if bomID != 2 {
+		return nil, nil
+	}
+	return []types.BoMAlternate{
+		{ID: 21, BoMID: 2, ComponentID: 4, Priority: 2, ConversionRatio: 1},
+		{ID: 20, BoMID: 2, ComponentID: 5, Priority: 1, ConversionRatio: 0.1},
+	}, nil
+}
+
+func (s shelfStore) stockOf(productID int) (float64, error) {
+	return s.stock[productID], nil
+}
+
+func TestAvailability(t *testing.T) {
+	src := shelfStore{stock: map[int]float64{2: 10, 3: 10, 4: 5, 5: 1}}
+	got, err := Availability(src, src.stockOf, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d lines, want 3", len(got))
+	}
+
+	brackets, screws, panel := got[0], got[1], got[2]
+	if brackets.Required != 8 || brackets.Available != 10 || brackets.Shortage != 0 {
+		t.Errorf("brackets = %+v, want 8 of 10 with no shortage", brackets)
+	}
+
+	// The ten screws cover ten of sixteen; the rest takes six tenths of a strip.
+	if screws.Required != 16 || screws.Shortage != 0 || len(screws.Allocations) != 2 {
+		t.Fatalf("screws = %+v, want 16 covered by two allocations", screws)
+	}
+	if a := screws.Allocations[0]; a.ComponentID != 3 || a.Substitute || a.Quantity != 10 {
+		t.Errorf("first allocation = %+v, want all ten screws", a)
+	}
+	if a := screws.Allocations[1]; a.ComponentID != 5 || a.AlternateID != 20 || !a.Substitute || math.Abs(a.Quantity-0.6) > 1e-9 {
+		t.Errorf("second allocation = %+v, want 0.6 screw strips", a)
+	}
+
+	// The first screw line took every screw, so the panel gets none.
+	if panel.ComponentName != "screw" || panel.Available != 0 || panel.Shortage != 8 || len(panel.Allocations) != 0 {
+		t.Errorf("panel screws = %+v, want a shortage of 8", panel)
+	}
+}
+
+func TestAvailabilityUnknownProduct(t *testing.T) {
+	if _, err := Availability(shelfStore{}, shelfStore{}.stockOf, 9, 1); err == nil {
+		t.Error("availability of a product that does not exist succeeded")
+	}
+}
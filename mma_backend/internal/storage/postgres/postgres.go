@@ -2,12 +2,23 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"mma_api/internal/apperr"
+	"mma_api/internal/bom"
+	"mma_api/internal/calendar"
 	"mma_api/internal/config"
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/planning"
+	"mma_api/internal/trace"
 	"mma_api/internal/types"
+	"sort"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Postgres struct {
@@ -105,6 +116,326 @@ func New(cfg *config.Config) (*Postgres, error) {
         created_at TIMESTAMP DEFAULT NOW(),
         updated_at TIMESTAMP DEFAULT NOW()
     );`,
+
+		`ALTER TABLE bom ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;`,
+
+		`ALTER TABLE bom ADD COLUMN IF NOT EXISTS scrap_percent DECIMAL(5,2) NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE bom ADD COLUMN IF NOT EXISTS phantom BOOLEAN NOT NULL DEFAULT FALSE;`,
+
+		`CREATE TABLE IF NOT EXISTS bom_headers (
+        product_id INT NOT NULL,
+        version INT NOT NULL,
+        yield_percent DECIMAL(5,2) NOT NULL DEFAULT 100 CHECK (yield_percent > 0 AND yield_percent <= 100),
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW(),
+        PRIMARY KEY (product_id, version)
+    );`,
+
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS purchase_cost DECIMAL(12,4) NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE work_centers ADD COLUMN IF NOT EXISTS labour_rate DECIMAL(10,2) NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE work_centers ADD COLUMN IF NOT EXISTS overhead_rate DECIMAL(10,2) NOT NULL DEFAULT 0;`,
+
+		`CREATE TABLE IF NOT EXISTS product_costs (
+        id SERIAL PRIMARY KEY,
+        product_id INT NOT NULL,
+        lot_size DECIMAL(10,2) NOT NULL,
+        material_cost DECIMAL(14,4) NOT NULL,
+        labour_cost DECIMAL(14,4) NOT NULL,
+        overhead_cost DECIMAL(14,4) NOT NULL,
+        total_cost DECIMAL(14,4) NOT NULL,
+        rolled_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS routing_operations (
+        id SERIAL PRIMARY KEY,
+        product_id INT NOT NULL,
+        sequence INT NOT NULL,
+        name VARCHAR(100) NOT NULL,
+        work_center_id INT NOT NULL,
+        setup_minutes DECIMAL(10,2) NOT NULL DEFAULT 0,
+        run_minutes_per_unit DECIMAL(10,2) NOT NULL DEFAULT 0,
+        instructions TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`ALTER TABLE bom ADD COLUMN IF NOT EXISTS operation_id INT;`,
+
+		`CREATE TABLE IF NOT EXISTS bom_alternates (
+        id SERIAL PRIMARY KEY,
+        bom_id INT NOT NULL,
+        component_id INT NOT NULL,
+        priority INT NOT NULL DEFAULT 1,
+        conversion_ratio DECIMAL(10,4) NOT NULL DEFAULT 1 CHECK (conversion_ratio > 0),
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW(),
+        UNIQUE (bom_id, component_id)
+    );`,
+
+		`ALTER TABLE manufacturing_orders DROP CONSTRAINT IF EXISTS manufacturing_orders_status_check;`,
+
+		`ALTER TABLE manufacturing_orders ADD CONSTRAINT manufacturing_orders_status_check
+        CHECK (status IN ('draft', 'confirmed', 'in_progress', 'on_hold', 'done', 'cancelled'));`,
+
+		`CREATE TABLE IF NOT EXISTS mo_transitions (
+        id SERIAL PRIMARY KEY,
+        mo_id INT NOT NULL,
+        action VARCHAR(20) NOT NULL,
+        from_status VARCHAR(20) NOT NULL,
+        to_status VARCHAR(20) NOT NULL,
+        actor_id INT,
+        created_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`ALTER TABLE work_orders ALTER COLUMN step_name TYPE VARCHAR(100);`,
+
+		`ALTER TABLE work_orders ADD COLUMN IF NOT EXISTS sequence INT NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE work_orders ADD COLUMN IF NOT EXISTS operation_id INT;`,
+
+		`ALTER TABLE work_orders ADD COLUMN IF NOT EXISTS planned_minutes DECIMAL(12,2) NOT NULL DEFAULT 0;`,
+
+		`CREATE TABLE IF NOT EXISTS material_reservations (
+        id SERIAL PRIMARY KEY,
+        mo_id INT NOT NULL,
+        bom_id INT NOT NULL,
+        component_id INT NOT NULL,
+        quantity DECIMAL(12,4) NOT NULL,
+        substitute BOOLEAN NOT NULL DEFAULT FALSE,
+        alternate_id INT,
+        status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'released', 'consumed')),
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS consumption_mode VARCHAR(20) NOT NULL DEFAULT 'backflush'
+        CHECK (consumption_mode IN ('backflush', 'manual'));`,
+
+		`ALTER TABLE manufacturing_orders ADD COLUMN IF NOT EXISTS produced_quantity INT NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE manufacturing_orders ADD COLUMN IF NOT EXISTS origin_mo_id INT REFERENCES manufacturing_orders(id);`,
+
+		`ALTER TABLE manufacturing_orders ADD COLUMN IF NOT EXISTS parent_mo_id INT REFERENCES manufacturing_orders(id);`,
+
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS tracking VARCHAR(10) NOT NULL DEFAULT 'none'
+        CHECK (tracking IN ('none', 'lot', 'serial'));`,
+
+		`ALTER TABLE inventory ADD COLUMN IF NOT EXISTS lot_number VARCHAR(100);`,
+
+		`ALTER TABLE inventory ADD COLUMN IF NOT EXISTS serial_number VARCHAR(100);`,
+
+		`CREATE INDEX IF NOT EXISTS inventory_lot_idx ON inventory (product_id, lot_number, serial_number);`,
+
+		`ALTER TABLE manufacturing_orders ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 1
+        CHECK (priority BETWEEN 0 AND 3);`,
+
+		`ALTER TABLE work_orders ADD COLUMN IF NOT EXISTS queue_rank DOUBLE PRECISION;`,
+
+		`CREATE INDEX IF NOT EXISTS work_orders_queue_idx ON work_orders (work_center_id, queue_rank);`,
+
+		`ALTER TABLE work_orders DROP CONSTRAINT IF EXISTS work_orders_status_check;`,
+
+		`ALTER TABLE work_orders ADD CONSTRAINT work_orders_status_check
+        CHECK (status IN ('pending', 'in_progress', 'paused', 'completed'));`,
+
+		`ALTER TABLE work_orders ADD COLUMN IF NOT EXISTS actual_minutes DECIMAL(12,2) NOT NULL DEFAULT 0;`,
+
+		`CREATE TABLE IF NOT EXISTS work_order_time_logs (
+        id SERIAL PRIMARY KEY,
+        work_order_id INT NOT NULL REFERENCES work_orders(id),
+        worker_id INT NOT NULL,
+        started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        ended_at TIMESTAMP,
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS worker_qualifications (
+        id SERIAL PRIMARY KEY,
+        worker_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        work_center_id INT NOT NULL REFERENCES work_centers(id) ON DELETE CASCADE,
+        created_at TIMESTAMP DEFAULT NOW(),
+        UNIQUE (worker_id, work_center_id)
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS work_order_dependencies (
+        id SERIAL PRIMARY KEY,
+        work_order_id INT NOT NULL REFERENCES work_orders(id) ON DELETE CASCADE,
+        predecessor_id INT NOT NULL REFERENCES work_orders(id) ON DELETE CASCADE,
+        created_at TIMESTAMP DEFAULT NOW(),
+        UNIQUE (work_order_id, predecessor_id),
+        CHECK (work_order_id <> predecessor_id)
+    );`,
+
+		`ALTER TABLE manufacturing_orders ADD COLUMN IF NOT EXISTS scrapped_quantity INT NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE work_orders ADD COLUMN IF NOT EXISTS rework_of_id INT REFERENCES work_orders(id);`,
+
+		`CREATE TABLE IF NOT EXISTS scrap_reasons (
+        id SERIAL PRIMARY KEY,
+        code VARCHAR(30) NOT NULL UNIQUE,
+        description TEXT NOT NULL DEFAULT '',
+        active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS scrap_records (
+        id SERIAL PRIMARY KEY,
+        work_order_id INT NOT NULL REFERENCES work_orders(id),
+        mo_id INT NOT NULL REFERENCES manufacturing_orders(id),
+        product_id INT NOT NULL,
+        work_center_id INT,
+        reason_id INT NOT NULL REFERENCES scrap_reasons(id),
+        quantity INT NOT NULL CHECK (quantity > 0),
+        rework BOOLEAN NOT NULL DEFAULT FALSE,
+        rework_work_order_id INT REFERENCES work_orders(id),
+        reported_by INT NOT NULL,
+        notes TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS labour_entries (
+        id SERIAL PRIMARY KEY,
+        work_order_id INT NOT NULL REFERENCES work_orders(id) ON DELETE CASCADE,
+        worker_id INT NOT NULL REFERENCES users(id),
+        clock_in TIMESTAMP NOT NULL DEFAULT NOW(),
+        clock_out TIMESTAMP,
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW(),
+        CHECK (clock_out IS NULL OR clock_out >= clock_in)
+    );`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS labour_entries_open_idx ON labour_entries (worker_id) WHERE clock_out IS NULL;`,
+
+		`CREATE TABLE IF NOT EXISTS skills (
+        id SERIAL PRIMARY KEY,
+        code VARCHAR(50) NOT NULL UNIQUE,
+        name VARCHAR(100) NOT NULL,
+        description TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS user_skills (
+        id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        skill_id INT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+        certified_at DATE,
+        expires_at DATE,
+        reference VARCHAR(100) NOT NULL DEFAULT '',
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW(),
+        UNIQUE (user_id, skill_id)
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS work_center_skills (
+        work_center_id INT NOT NULL REFERENCES work_centers(id) ON DELETE CASCADE,
+        skill_id INT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+        PRIMARY KEY (work_center_id, skill_id)
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS operation_skills (
+        operation_id INT NOT NULL REFERENCES routing_operations(id) ON DELETE CASCADE,
+        skill_id INT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+        PRIMARY KEY (operation_id, skill_id)
+    );`,
+
+		`ALTER TABLE work_centers ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'available'
+        CHECK (status IN ('available','down','maintenance'));`,
+
+		`CREATE TABLE IF NOT EXISTS shift_patterns (
+        id SERIAL PRIMARY KEY,
+        name VARCHAR(100) NOT NULL,
+        is_default BOOLEAN NOT NULL DEFAULT false,
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS shift_patterns_default_idx ON shift_patterns (is_default) WHERE is_default;`,
+
+		`CREATE TABLE IF NOT EXISTS shifts (
+        id SERIAL PRIMARY KEY,
+        shift_pattern_id INT NOT NULL REFERENCES shift_patterns(id) ON DELETE CASCADE,
+        name VARCHAR(50) NOT NULL DEFAULT '',
+        weekday INT NOT NULL CHECK (weekday BETWEEN 1 AND 7),
+        start_time TIME NOT NULL,
+        end_time TIME NOT NULL,
+        CHECK (start_time <> end_time)
+    );`,
+
+		`ALTER TABLE work_centers ADD COLUMN IF NOT EXISTS shift_pattern_id INT REFERENCES shift_patterns(id) ON DELETE SET NULL;`,
+
+		`CREATE TABLE IF NOT EXISTS holidays (
+        id SERIAL PRIMARY KEY,
+        date DATE NOT NULL,
+        work_center_id INT REFERENCES work_centers(id) ON DELETE CASCADE,
+        name VARCHAR(100) NOT NULL DEFAULT '',
+        created_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS holidays_date_idx ON holidays (date, COALESCE(work_center_id, 0));`,
+
+		`CREATE TABLE IF NOT EXISTS downtime_events (
+        id SERIAL PRIMARY KEY,
+        work_center_id INT NOT NULL REFERENCES work_centers(id) ON DELETE CASCADE,
+        category VARCHAR(20) NOT NULL CHECK (category IN ('breakdown','changeover','no_material')),
+        started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        ended_at TIMESTAMP,
+        notes TEXT NOT NULL DEFAULT '',
+        reported_by INT NOT NULL REFERENCES users(id),
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW(),
+        CHECK (ended_at IS NULL OR ended_at >= started_at)
+    );`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS downtime_events_open_idx ON downtime_events (work_center_id) WHERE ended_at IS NULL;`,
+
+		`CREATE TABLE IF NOT EXISTS maintenance_plans (
+        id SERIAL PRIMARY KEY,
+        work_center_id INT NOT NULL REFERENCES work_centers(id) ON DELETE CASCADE,
+        name VARCHAR(100) NOT NULL,
+        description TEXT NOT NULL DEFAULT '',
+        trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('calendar','run_hours')),
+        interval_days INT CHECK (interval_days > 0),
+        interval_hours DECIMAL(10,2) CHECK (interval_hours > 0),
+        duration_minutes INT NOT NULL DEFAULT 60 CHECK (duration_minutes > 0),
+        active BOOLEAN NOT NULL DEFAULT true,
+        last_done_at TIMESTAMP,
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW(),
+        CHECK ((trigger = 'calendar' AND interval_days IS NOT NULL) OR (trigger = 'run_hours' AND interval_hours IS NOT NULL))
+    );`,
+
+		`CREATE TABLE IF NOT EXISTS maintenance_tasks (
+        id SERIAL PRIMARY KEY,
+        plan_id INT REFERENCES maintenance_plans(id) ON DELETE SET NULL,
+        work_center_id INT NOT NULL REFERENCES work_centers(id) ON DELETE CASCADE,
+        title VARCHAR(100) NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'planned' CHECK (status IN ('planned','in_progress','done')),
+        due_at TIMESTAMP NOT NULL,
+        planned_end TIMESTAMP NOT NULL,
+        started_at TIMESTAMP,
+        completed_at TIMESTAMP,
+        completed_by INT REFERENCES users(id),
+        run_hours DECIMAL(12,2) NOT NULL DEFAULT 0,
+        notes TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS maintenance_tasks_open_idx ON maintenance_tasks (plan_id) WHERE status <> 'done';`,
+
+		`ALTER TABLE bom ADD COLUMN IF NOT EXISTS work_center_id INT REFERENCES work_centers(id);`,
+
+		`ALTER TABLE bom ADD COLUMN IF NOT EXISTS setup_minutes DECIMAL(10,2) NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE bom ADD COLUMN IF NOT EXISTS run_minutes_per_unit DECIMAL(10,2) NOT NULL DEFAULT 0;`,
+
+		`ALTER TABLE downtime_events ADD COLUMN IF NOT EXISTS previous_status VARCHAR(20);`,
 	}
 
 	for _, q := range queries {
@@ -167,7 +498,7 @@ func (p *Postgres) UpdateUser(id int, name, role, email, password string) (*type
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with id %d not found", id)
+			return nil, apperr.Newf(apperr.NotFound, "user with id %d not found", id)
 		}
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -224,7 +555,7 @@ func (p *Postgres) GetUserByID(id int) (*types.User, error) {
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with id %d not found", id)
+			return nil, apperr.Newf(apperr.NotFound, "user with id %d not found", id)
 		}
 		return nil, fmt.Errorf("failed to fetch user: %w", err)
 	}
@@ -269,7 +600,7 @@ func (p *Postgres) GetUserByEmail(email string) (*types.User, error) {
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with email %s not found", email)
+			return nil, apperr.Newf(apperr.NotFound, "user with email %s not found", email)
 		}
 		return nil, fmt.Errorf("failed to fetch user: %w", err)
 	}
@@ -280,33 +611,67 @@ func (p *Postgres) GetUserByEmail(email string) (*types.User, error) {
 //------------------users--------Radiator-------------------------//
 
 // -----------------products-------Radiator------------------------//
-func (p *Postgres) CreateProduct(name, description, category, unit string) (*types.Product, error) {
-	query := `
-        INSERT INTO products (name, description, category, unit)
-        VALUES ($1, $2, $3, $4)
-        RETURNING id, name, description, category, unit, created_at, updated_at
-    `
+const productColumns = `id, name, description, category, unit, purchase_cost, consumption_mode, tracking, created_at, updated_at`
 
+func scanProduct(row rowScanner) (types.Product, error) {
 	var product types.Product
-	err := p.db.QueryRow(query, name, description, category, unit).Scan(
+	err := row.Scan(
 		&product.ID,
 		&product.Name,
 		&product.Description,
 		&product.Category,
 		&product.Unit,
+		&product.PurchaseCost,
+		&product.ConsumptionMode,
+		&product.Tracking,
 		&product.CreatedAt,
 		&product.UpdatedAt,
 	)
+	return product, err
+}
+
+func (p *Postgres) CreateProduct(product types.Product) (*types.Product, error) {
+	query := `
+        INSERT INTO products (name, description, category, unit, purchase_cost, consumption_mode, tracking)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING ` + productColumns
 
+	created, err := scanProduct(p.db.QueryRow(query, product.Name, product.Description, product.Category,
+		product.Unit, product.PurchaseCost, product.ConsumptionMode, product.Tracking))
 	if err != nil {
 		return nil, fmt.Errorf("could not create product: %w", err)
 	}
 
-	return &product, nil
+	return &created, nil
+}
+func (p *Postgres) UpdateProduct(product types.Product) (*types.Product, error) {
+	query := `
+        UPDATE products
+        SET name = $1,
+            description = $2,
+            category = $3,
+            unit = $4,
+            purchase_cost = $5,
+            consumption_mode = $6,
+            tracking = $7,
+            updated_at = NOW()
+        WHERE id = $8
+        RETURNING ` + productColumns
+
+	updated, err := scanProduct(p.db.QueryRow(query, product.Name, product.Description, product.Category,
+		product.Unit, product.PurchaseCost, product.ConsumptionMode, product.Tracking, product.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "product with id %d not found", product.ID)
+		}
+		return nil, fmt.Errorf("could not update product: %w", err)
+	}
+
+	return &updated, nil
 }
 func (p *Postgres) GetProducts() ([]types.Product, error) {
 	query := `
-        SELECT id, name, description, category, unit, created_at, updated_at
+        SELECT ` + productColumns + `
         FROM products
         ORDER BY id ASC
     `
@@ -320,16 +685,7 @@ func (p *Postgres) GetProducts() ([]types.Product, error) {
 	var products []types.Product
 
 	for rows.Next() {
-		var prod types.Product
-		err := rows.Scan(
-			&prod.ID,
-			&prod.Name,
-			&prod.Description,
-			&prod.Category,
-			&prod.Unit,
-			&prod.CreatedAt,
-			&prod.UpdatedAt,
-		)
+		prod, err := scanProduct(rows)
 		if err != nil {
 			return nil, fmt.Errorf("could not scan product: %w", err)
 		}
@@ -343,80 +699,172 @@ func (p *Postgres) GetProducts() ([]types.Product, error) {
 	return products, nil
 }
 func (p *Postgres) GetProductById(id int) (*types.Product, error) {
+	return bomSource{p.db}.GetProductById(id)
+}
+
+func (s bomSource) GetProductById(id int) (*types.Product, error) {
 	query := `
-		SELECT id, name, description, category, unit, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		WHERE id = $1
 	`
 
-	var product types.Product
-	err := p.db.QueryRow(query, id).Scan(
-		&product.ID,
-		&product.Name,
-		&product.Description,
-		&product.Category,
-		&product.Unit,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
+	product, err := scanProduct(s.q.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product with id %d not found", id)
+			return nil, apperr.Newf(apperr.NotFound, "product with id %d not found", id)
 		}
 		return nil, fmt.Errorf("could not fetch product: %w", err)
 	}
 
 	return &product, nil
 }
-func (p *Postgres) CreateBoM(productID, componentID int, quantity float64, operationName string) (*types.BoM, error) {
+
+const bomColumns = `id, product_id, component_id, quantity, operation_name, operation_id, work_center_id, setup_minutes,
+	run_minutes_per_unit, version, scrap_percent, phantom, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBoM(row rowScanner) (types.BoM, error) {
+	var bom types.BoM
+	err := row.Scan(
+		&bom.ID,
+		&bom.ProductID,
+		&bom.ComponentID,
+		&bom.Quantity,
+		&bom.OperationName,
+		&bom.OperationID,
+		&bom.WorkCenterID,
+		&bom.SetupMinutes,
+		&bom.RunMinutesPerUnit,
+		&bom.Version,
+		&bom.ScrapPercent,
+		&bom.Phantom,
+		&bom.CreatedAt,
+		&bom.UpdatedAt,
+	)
+	return bom, err
+}
+
+func (p *Postgres) CreateBoM(line types.BoM) (*types.BoM, error) {
 
 	var exists bool
-	err := p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	err := p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", line.ProductID).Scan(&exists)
 	if err != nil {
 		return nil, fmt.Errorf("error checking product existence: %w", err)
 	}
 	if !exists {
-		return nil, fmt.Errorf("product with id %d does not exist", productID)
+		return nil, fmt.Errorf("product with id %d does not exist", line.ProductID)
 	}
 
-	err = p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", componentID).Scan(&exists)
+	err = p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", line.ComponentID).Scan(&exists)
 	if err != nil {
 		return nil, fmt.Errorf("error checking component existence: %w", err)
 	}
 	if !exists {
-		return nil, fmt.Errorf("component product with id %d does not exist", componentID)
+		return nil, fmt.Errorf("component product with id %d does not exist", line.ComponentID)
+	}
+
+	// a line consumed at a routing operation takes its operation name from it
+	if line.OperationID != nil {
+		var name string
+		err = p.db.QueryRow("SELECT name FROM routing_operations WHERE id = $1 AND product_id = $2",
+			*line.OperationID, line.ProductID).Scan(&name)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("%w: operation %d is not part of the routing of product %d",
+					bom.ErrInvalidLine, *line.OperationID, line.ProductID)
+			}
+			return nil, fmt.Errorf("error checking operation: %w", err)
+		}
+		line.OperationName = name
+	}
+
+	// version 0 means "add to the current version", which is 1 for a product without a BoM yet
+	if line.Version == 0 {
+		err = p.db.QueryRow("SELECT COALESCE(MAX(version), 1) FROM bom WHERE product_id = $1", line.ProductID).Scan(&line.Version)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve bom version: %w", err)
+		}
+	}
+
+	// an operation named without a routing is defined once per version: every
+	// line consumed at it must agree on where it runs and how long it takes
+	if line.OperationID == nil && line.OperationName != "" {
+		var (
+			wcID       *int
+			setup, run float64
+		)
+		err = p.db.QueryRow(`
+			SELECT work_center_id, setup_minutes, run_minutes_per_unit
+			FROM bom
+			WHERE product_id = $1 AND version = $2 AND operation_id IS NULL AND operation_name = $3
+			ORDER BY id ASC LIMIT 1`, line.ProductID, line.Version, line.OperationName).Scan(&wcID, &setup, &run)
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return nil, fmt.Errorf("error checking operation: %w", err)
+		case !sameRef(wcID, line.WorkCenterID) || setup != line.SetupMinutes || run != line.RunMinutesPerUnit:
+			return nil, fmt.Errorf("%w: operation %q of version %d is already defined with another work center or times",
+				bom.ErrInvalidLine, line.OperationName, line.Version)
+		}
 	}
 
 	query := `
-		INSERT INTO bom (product_id, component_id, quantity, operation_name)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, product_id, component_id, quantity, operation_name, created_at, updated_at
-	`
+		INSERT INTO bom (product_id, component_id, quantity, operation_name, operation_id, work_center_id,
+		                 setup_minutes, run_minutes_per_unit, version, scrap_percent, phantom)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + bomColumns
 
-	var bom types.BoM
-	err = p.db.QueryRow(query, productID, componentID, quantity, operationName).Scan(
-		&bom.ID,
-		&bom.ProductID,
-		&bom.ComponentID,
-		&bom.Quantity,
-		&bom.OperationName,
-		&bom.CreatedAt,
-		&bom.UpdatedAt,
-	)
+	created, err := scanBoM(p.db.QueryRow(query, line.ProductID, line.ComponentID, line.Quantity,
+		line.OperationName, line.OperationID, line.WorkCenterID, line.SetupMinutes, line.RunMinutesPerUnit,
+		line.Version, line.ScrapPercent, line.Phantom))
+	if violates(err, "bom_work_center_id_fkey") {
+		return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", *line.WorkCenterID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not create bom: %w", err)
 	}
 
-	return &bom, nil
+	return &created, nil
+}
+
+// violates reports whether err was raised by the named constraint or unique
+// index.
+func violates(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == constraint
+}
+
+// sameRef reports whether two optional ids are both unset or equal.
+func sameRef(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
 }
+
+// GetBoM returns the lines of the latest BoM version of a product.
 func (p *Postgres) GetBoM(productID int) ([]types.BoM, error) {
+	return p.GetBoMVersion(productID, 0)
+}
+
+// GetBoMVersion returns the lines of one BoM version; version 0 selects the latest.
+func (p *Postgres) GetBoMVersion(productID, version int) ([]types.BoM, error) {
+	return bomSource{p.db}.GetBoMVersion(productID, version)
+}
+
+func (s bomSource) GetBoMVersion(productID, version int) ([]types.BoM, error) {
 	query := `
-		SELECT id, product_id, component_id, quantity, operation_name, created_at, updated_at
+		SELECT ` + bomColumns + `
 		FROM bom
 		WHERE product_id = $1
+		  AND version = CASE WHEN $2 = 0
+		                     THEN (SELECT COALESCE(MAX(version), 1) FROM bom WHERE product_id = $1)
+		                     ELSE $2 END
+		ORDER BY id ASC
 	`
 
-	rows, err := p.db.Query(query, productID)
+	rows, err := s.q.Query(query, productID, version)
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch BoM: %w", err)
 	}
@@ -424,16 +872,8 @@ func (p *Postgres) GetBoM(productID int) ([]types.BoM, error) {
 
 	var boms []types.BoM
 	for rows.Next() {
-		var bom types.BoM
-		if err := rows.Scan(
-			&bom.ID,
-			&bom.ProductID,
-			&bom.ComponentID,
-			&bom.Quantity,
-			&bom.OperationName,
-			&bom.CreatedAt,
-			&bom.UpdatedAt,
-		); err != nil {
+		bom, err := scanBoM(rows)
+		if err != nil {
 			return nil, fmt.Errorf("could not scan bom row: %w", err)
 		}
 		boms = append(boms, bom)
@@ -446,8 +886,5100 @@ func (p *Postgres) GetBoM(productID int) ([]types.BoM, error) {
 	return boms, nil
 }
 
-//-----------------products-------Radiator------------------------//
+// GetBoMYield returns the output yield of a BoM version (0 = latest).
+// A BoM without an explicit yield produces 100%.
+func (p *Postgres) GetBoMYield(productID, version int) (float64, error) {
+	return bomSource{p.db}.GetBoMYield(productID, version)
+}
 
-//-----------------MO------------Radiator-------------------------//
+func (s bomSource) GetBoMYield(productID, version int) (float64, error) {
+	query := `
+		SELECT COALESCE((
+			SELECT yield_percent FROM bom_headers
+			WHERE product_id = $1
+			  AND version = CASE WHEN $2 = 0
+			                     THEN (SELECT COALESCE(MAX(version), 1) FROM bom WHERE product_id = $1)
+			                     ELSE $2 END
+		), 100)
+	`
+	var yield float64
+	if err := s.q.QueryRow(query, productID, version).Scan(&yield); err != nil {
+		return 0, fmt.Errorf("could not fetch bom yield: %w", err)
+	}
+	return yield, nil
+}
 
-//-----------------MO------------Radiator-------------------------//
+func (p *Postgres) SetBoMYield(productID, version int, yieldPercent float64) error {
+	if version == 0 {
+		err := p.db.QueryRow("SELECT COALESCE(MAX(version), 1) FROM bom WHERE product_id = $1", productID).Scan(&version)
+		if err != nil {
+			return fmt.Errorf("could not resolve bom version: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO bom_headers (product_id, version, yield_percent)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, version)
+		DO UPDATE SET yield_percent = EXCLUDED.yield_percent, updated_at = NOW()
+	`
+	if _, err := p.db.Exec(query, productID, version, yieldPercent); err != nil {
+		return fmt.Errorf("could not set bom yield: %w", err)
+	}
+	return nil
+}
+
+// GetOperationCosts returns the timed operations of a product together with
+// the rates of the work centers they run at.
+func (p *Postgres) GetOperationCosts(productID int) ([]types.OperationCost, error) {
+	query := `
+		SELECT o.id, o.name, o.work_center_id, o.setup_minutes, o.run_minutes_per_unit,
+		       COALESCE(wc.labour_rate, 0), COALESCE(wc.overhead_rate, 0)
+		FROM routing_operations o
+		LEFT JOIN work_centers wc ON wc.id = o.work_center_id
+		WHERE o.product_id = $1
+		ORDER BY o.sequence ASC, o.id ASC
+	`
+
+	rows, err := p.db.Query(query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch operation costs: %w", err)
+	}
+	defer rows.Close()
+
+	var ops []types.OperationCost
+	for rows.Next() {
+		var op types.OperationCost
+		if err := rows.Scan(
+			&op.OperationID,
+			&op.Name,
+			&op.WorkCenterID,
+			&op.SetupMinutes,
+			&op.RunMinutesPerUnit,
+			&op.LabourRate,
+			&op.OverheadRate,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan operation cost: %w", err)
+		}
+		ops = append(ops, op)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ops, nil
+}
+
+func (p *Postgres) SaveProductCost(cost types.ProductCost) (*types.ProductCost, error) {
+	query := `
+		INSERT INTO product_costs (product_id, lot_size, material_cost, labour_cost, overhead_cost, total_cost)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, product_id, lot_size, material_cost, labour_cost, overhead_cost, total_cost, rolled_at
+	`
+
+	var saved types.ProductCost
+	err := p.db.QueryRow(query, cost.ProductID, cost.LotSize, cost.MaterialCost, cost.LabourCost,
+		cost.OverheadCost, cost.TotalCost).Scan(
+		&saved.ID,
+		&saved.ProductID,
+		&saved.LotSize,
+		&saved.MaterialCost,
+		&saved.LabourCost,
+		&saved.OverheadCost,
+		&saved.TotalCost,
+		&saved.RolledAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not save product cost: %w", err)
+	}
+	return &saved, nil
+}
+
+func (p *Postgres) GetProductCostHistory(productID int) ([]types.ProductCost, error) {
+	query := `
+		SELECT id, product_id, lot_size, material_cost, labour_cost, overhead_cost, total_cost, rolled_at
+		FROM product_costs
+		WHERE product_id = $1
+		ORDER BY rolled_at DESC, id DESC
+	`
+
+	rows, err := p.db.Query(query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch cost history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []types.ProductCost
+	for rows.Next() {
+		var c types.ProductCost
+		if err := rows.Scan(
+			&c.ID,
+			&c.ProductID,
+			&c.LotSize,
+			&c.MaterialCost,
+			&c.LabourCost,
+			&c.OverheadCost,
+			&c.TotalCost,
+			&c.RolledAt,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan cost row: %w", err)
+		}
+		history = append(history, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return history, nil
+}
+
+func (p *Postgres) CreateBoMAlternate(productID int, alt types.BoMAlternate) (*types.BoMAlternate, error) {
+	var primary int
+	err := p.db.QueryRow("SELECT component_id FROM bom WHERE id = $1 AND product_id = $2", alt.BoMID, productID).Scan(&primary)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "bom line %d not found for product %d", alt.BoMID, productID)
+		}
+		return nil, fmt.Errorf("error checking bom line: %w", err)
+	}
+	if primary == alt.ComponentID {
+		return nil, fmt.Errorf("component %d is already the primary component of the line", alt.ComponentID)
+	}
+
+	var exists bool
+	err = p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", alt.ComponentID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error checking component existence: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("component product with id %d does not exist", alt.ComponentID)
+	}
+
+	query := `
+		INSERT INTO bom_alternates (bom_id, component_id, priority, conversion_ratio)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, bom_id, component_id, priority, conversion_ratio, created_at, updated_at
+	`
+
+	var created types.BoMAlternate
+	err = p.db.QueryRow(query, alt.BoMID, alt.ComponentID, alt.Priority, alt.ConversionRatio).Scan(
+		&created.ID,
+		&created.BoMID,
+		&created.ComponentID,
+		&created.Priority,
+		&created.ConversionRatio,
+		&created.CreatedAt,
+		&created.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create bom alternate: %w", err)
+	}
+	return &created, nil
+}
+
+// GetBoMAlternates returns the substitutes of a BoM line, most preferred first.
+func (p *Postgres) GetBoMAlternates(bomID int) ([]types.BoMAlternate, error) {
+	return bomSource{p.db}.GetBoMAlternates(bomID)
+}
+
+func (s bomSource) GetBoMAlternates(bomID int) ([]types.BoMAlternate, error) {
+	query := `
+		SELECT id, bom_id, component_id, priority, conversion_ratio, created_at, updated_at
+		FROM bom_alternates
+		WHERE bom_id = $1
+		ORDER BY priority ASC, id ASC
+	`
+
+	rows, err := s.q.Query(query, bomID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch bom alternates: %w", err)
+	}
+	defer rows.Close()
+
+	var alts []types.BoMAlternate
+	for rows.Next() {
+		var a types.BoMAlternate
+		if err := rows.Scan(
+			&a.ID,
+			&a.BoMID,
+			&a.ComponentID,
+			&a.Priority,
+			&a.ConversionRatio,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan bom alternate: %w", err)
+		}
+		alts = append(alts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return alts, nil
+}
+
+func (p *Postgres) DeleteBoMAlternate(bomID, id int) error {
+	result, err := p.db.Exec("DELETE FROM bom_alternates WHERE id = $1 AND bom_id = $2", id, bomID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bom alternate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no alternate found with id %d", id)
+	}
+	return nil
+}
+
+//-----------------products-------Radiator------------------------//
+
+// -----------------routing-------Radiator-------------------------//
+const routingColumns = `id, product_id, sequence, name, work_center_id, setup_minutes, run_minutes_per_unit, instructions, created_at, updated_at`
+
+func scanRoutingOperation(row rowScanner) (types.RoutingOperation, error) {
+	var op types.RoutingOperation
+	err := row.Scan(
+		&op.ID,
+		&op.ProductID,
+		&op.Sequence,
+		&op.Name,
+		&op.WorkCenterID,
+		&op.SetupMinutes,
+		&op.RunMinutesPerUnit,
+		&op.Instructions,
+		&op.CreatedAt,
+		&op.UpdatedAt,
+	)
+	return op, err
+}
+
+func (p *Postgres) checkRoutingRefs(op types.RoutingOperation) error {
+	var exists bool
+	err := p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", op.ProductID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error checking product existence: %w", err)
+	}
+	if !exists {
+		return apperr.Newf(apperr.NotFound, "product with id %d not found", op.ProductID)
+	}
+
+	err = p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM work_centers WHERE id = $1)", op.WorkCenterID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error checking work center existence: %w", err)
+	}
+	if !exists {
+		return apperr.Newf(apperr.NotFound, "work center with id %d not found", op.WorkCenterID)
+	}
+	return nil
+}
+
+func (p *Postgres) CreateRoutingOperation(op types.RoutingOperation) (*types.RoutingOperation, error) {
+	if err := p.checkRoutingRefs(op); err != nil {
+		return nil, err
+	}
+
+	// without an explicit sequence the operation goes to the end of the routing
+	if op.Sequence == 0 {
+		err := p.db.QueryRow("SELECT COALESCE(MAX(sequence), 0) + 10 FROM routing_operations WHERE product_id = $1",
+			op.ProductID).Scan(&op.Sequence)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve operation sequence: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO routing_operations (product_id, sequence, name, work_center_id, setup_minutes, run_minutes_per_unit, instructions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + routingColumns
+
+	created, err := scanRoutingOperation(p.db.QueryRow(query, op.ProductID, op.Sequence, op.Name, op.WorkCenterID,
+		op.SetupMinutes, op.RunMinutesPerUnit, op.Instructions))
+	if err != nil {
+		return nil, fmt.Errorf("could not create routing operation: %w", err)
+	}
+	return &created, nil
+}
+
+func (p *Postgres) UpdateRoutingOperation(op types.RoutingOperation) (*types.RoutingOperation, error) {
+	if err := p.checkRoutingRefs(op); err != nil {
+		return nil, err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE routing_operations
+		SET sequence = $1,
+		    name = $2,
+		    work_center_id = $3,
+		    setup_minutes = $4,
+		    run_minutes_per_unit = $5,
+		    instructions = $6,
+		    updated_at = NOW()
+		WHERE id = $7 AND product_id = $8
+		RETURNING ` + routingColumns
+
+	updated, err := scanRoutingOperation(tx.QueryRow(query, op.Sequence, op.Name, op.WorkCenterID,
+		op.SetupMinutes, op.RunMinutesPerUnit, op.Instructions, op.ID, op.ProductID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "operation with id %d not found", op.ID)
+		}
+		return nil, fmt.Errorf("could not update routing operation: %w", err)
+	}
+
+	// keep the denormalised name on BoM lines in step with the routing
+	if _, err := tx.Exec("UPDATE bom SET operation_name = $1, updated_at = NOW() WHERE operation_id = $2",
+		updated.Name, updated.ID); err != nil {
+		return nil, fmt.Errorf("could not update bom lines: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &updated, nil
+}
+
+func (p *Postgres) DeleteRoutingOperation(productID, id int) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM routing_operations WHERE id = $1 AND product_id = $2", id, productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete routing operation: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.Newf(apperr.NotFound, "operation with id %d not found", id)
+	}
+
+	if _, err := tx.Exec("UPDATE bom SET operation_id = NULL, updated_at = NOW() WHERE operation_id = $1", id); err != nil {
+		return fmt.Errorf("could not detach bom lines: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetRouting returns the operations of a product in execution order.
+func (p *Postgres) GetRouting(productID int) ([]types.RoutingOperation, error) {
+	query := `
+		SELECT ` + routingColumns + `
+		FROM routing_operations
+		WHERE product_id = $1
+		ORDER BY sequence ASC, id ASC
+	`
+
+	rows, err := p.db.Query(query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch routing: %w", err)
+	}
+	defer rows.Close()
+
+	var ops []types.RoutingOperation
+	for rows.Next() {
+		op, err := scanRoutingOperation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan routing operation: %w", err)
+		}
+		ops = append(ops, op)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	// an empty routing is only an answer for a product that exists
+	if len(ops) == 0 {
+		var exists bool
+		if err := p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("error checking product existence: %w", err)
+		}
+		if !exists {
+			return nil, apperr.Newf(apperr.NotFound, "product with id %d not found", productID)
+		}
+	}
+
+	return ops, nil
+}
+
+//-----------------routing-------Radiator-------------------------//
+
+// -----------------MO------------Radiator-------------------------//
+const moColumns = `id, product_id, quantity, produced_quantity, scrapped_quantity, status, priority, start_date, due_date, assigned_manager_id, origin_mo_id, parent_mo_id, created_at, updated_at`
+
+func scanMO(row rowScanner) (types.ManufacturingOrder, error) {
+	var mo types.ManufacturingOrder
+	err := row.Scan(
+		&mo.ID,
+		&mo.ProductID,
+		&mo.Quantity,
+		&mo.ProducedQuantity,
+		&mo.ScrappedQuantity,
+		&mo.Status,
+		&mo.Priority,
+		&mo.StartDate,
+		&mo.DueDate,
+		&mo.AssignedManagerID,
+		&mo.OriginMOID,
+		&mo.ParentMOID,
+		&mo.CreatedAt,
+		&mo.UpdatedAt,
+	)
+	return mo, err
+}
+
+func (p *Postgres) CreateMO(mo types.ManufacturingOrder) (*types.ManufacturingOrder, error) {
+	query := `
+		INSERT INTO manufacturing_orders (product_id, quantity, status, priority, start_date, due_date, assigned_manager_id, origin_mo_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + moColumns
+
+	created, err := scanMO(p.db.QueryRow(query, mo.ProductID, mo.Quantity, mo.Status, mo.Priority, mo.StartDate,
+		mo.DueDate, mo.AssignedManagerID, mo.OriginMOID))
+	if err != nil {
+		return nil, fmt.Errorf("could not create manufacturing order: %w", err)
+	}
+	return &created, nil
+}
+
+// GetMOs lists the orders matching filter together with their remaining
+// planned work.
+func (p *Postgres) GetMOs(filter types.MOFilter) ([]types.MOSummary, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(filter.Statuses) > 0 {
+		where = append(where, "m.status = ANY("+arg(pq.Array(filter.Statuses))+")")
+	}
+	if filter.ProductID != 0 {
+		where = append(where, "m.product_id = "+arg(filter.ProductID))
+	}
+	if filter.ManagerID != 0 {
+		where = append(where, "m.assigned_manager_id = "+arg(filter.ManagerID))
+	}
+	if filter.StartFrom != nil {
+		where = append(where, "m.start_date >= "+arg(*filter.StartFrom))
+	}
+	if filter.StartTo != nil {
+		where = append(where, "m.start_date <= "+arg(*filter.StartTo))
+	}
+	if filter.DueFrom != nil {
+		where = append(where, "m.due_date >= "+arg(*filter.DueFrom))
+	}
+	if filter.DueTo != nil {
+		where = append(where, "m.due_date <= "+arg(*filter.DueTo))
+	}
+
+	query := `SELECT ` + moColumns + ` FROM manufacturing_orders m`
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n\t\tORDER BY m.id ASC"
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch manufacturing orders: %w", err)
+	}
+	defer rows.Close()
+
+	var mos []types.MOSummary
+	for rows.Next() {
+		var mo types.MOSummary
+		err := rows.Scan(
+			&mo.ID,
+			&mo.ProductID,
+			&mo.Quantity,
+			&mo.ProducedQuantity,
+			&mo.ScrappedQuantity,
+			&mo.Status,
+			&mo.Priority,
+			&mo.StartDate,
+			&mo.DueDate,
+			&mo.AssignedManagerID,
+			&mo.OriginMOID,
+			&mo.ParentMOID,
+			&mo.CreatedAt,
+			&mo.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan manufacturing order: %w", err)
+		}
+		mos = append(mos, mo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	ids := make([]int64, len(mos))
+	for i, mo := range mos {
+		ids[i] = int64(mo.ID)
+	}
+	work, err := remainingWork(p.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mos {
+		mos[i].RemainingWork = work[mos[i].ID]
+		if mos[i].RemainingWork == nil {
+			mos[i].RemainingWork = []types.WorkStep{}
+		}
+		for _, step := range mos[i].RemainingWork {
+			mos[i].RemainingMinutes += step.Minutes
+		}
+	}
+
+	return mos, nil
+}
+
+// remainingWork returns the planned work each order still has to do, in
+// routing order: its open work orders once they exist, its routing before
+// that.
+func remainingWork(q queryer, moIDs []int64) (map[int][]types.WorkStep, error) {
+	rows, err := q.Query(`
+		SELECT m.id, s.work_center_id, s.minutes
+		FROM manufacturing_orders m
+		CROSS JOIN LATERAL (
+			SELECT wo.work_center_id, wo.planned_minutes AS minutes, wo.sequence, wo.id
+			FROM work_orders wo
+			WHERE wo.mo_id = m.id AND wo.status <> 'completed'
+			UNION ALL
+			SELECT r.work_center_id, r.setup_minutes + r.run_minutes_per_unit * m.quantity, r.sequence, r.id
+			FROM routing_operations r
+			WHERE r.product_id = m.product_id
+			  AND NOT EXISTS (SELECT 1 FROM work_orders wo WHERE wo.mo_id = m.id)
+		) s
+		WHERE m.id = ANY($1)
+		ORDER BY m.id ASC, s.sequence ASC, s.id ASC`, pq.Array(moIDs))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch remaining work: %w", err)
+	}
+	defer rows.Close()
+
+	work := map[int][]types.WorkStep{}
+	for rows.Next() {
+		var moID int
+		var step types.WorkStep
+		if err := rows.Scan(&moID, &step.WorkCenterID, &step.Minutes); err != nil {
+			return nil, fmt.Errorf("could not scan remaining work: %w", err)
+		}
+		work[moID] = append(work[moID], step)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return work, nil
+}
+
+func (p *Postgres) GetMOByID(id int) (*types.ManufacturingOrder, error) {
+	query := `SELECT ` + moColumns + ` FROM manufacturing_orders WHERE id = $1`
+
+	mo, err := scanMO(p.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "manufacturing order with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+	}
+	return &mo, nil
+}
+
+// UpdateMO rewrites the planning fields of an order that is still a draft.
+func (p *Postgres) UpdateMO(mo types.ManufacturingOrder) (*types.ManufacturingOrder, error) {
+	query := `
+		UPDATE manufacturing_orders
+		SET product_id = $1,
+		    quantity = $2,
+		    start_date = $3,
+		    due_date = $4,
+		    assigned_manager_id = $5,
+		    priority = $6,
+		    updated_at = NOW()
+		WHERE id = $7 AND status = 'draft'
+		RETURNING ` + moColumns
+
+	updated, err := scanMO(p.db.QueryRow(query, mo.ProductID, mo.Quantity, mo.StartDate, mo.DueDate,
+		mo.AssignedManagerID, mo.Priority, mo.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, getErr := p.GetMOByID(mo.ID); getErr != nil {
+				return nil, getErr
+			}
+			return nil, manufacturing.ErrNotEditable
+		}
+		return nil, fmt.Errorf("could not update manufacturing order: %w", err)
+	}
+	return &updated, nil
+}
+
+// SetMOPriority changes the priority of an order that is still open. Work
+// orders already queued keep their place; it only affects where the order's
+// future work orders are queued.
+func (p *Postgres) SetMOPriority(id, priority int) (*types.ManufacturingOrder, error) {
+	query := `
+		UPDATE manufacturing_orders
+		SET priority = $1, updated_at = NOW()
+		WHERE id = $2 AND status NOT IN ('done', 'cancelled')
+		RETURNING ` + moColumns
+
+	updated, err := scanMO(p.db.QueryRow(query, priority, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, getErr := p.GetMOByID(id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, manufacturing.ErrNotEditable
+		}
+		return nil, fmt.Errorf("could not update priority: %w", err)
+	}
+	return &updated, nil
+}
+
+// TransitionMO applies a state machine action to an order, checking its
+// guards and recording who did it.
+func (p *Postgres) TransitionMO(id int, action string, actorID int) (*types.ManufacturingOrder, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	mo, err := p.transitionMOTx(tx, id, action, actorID, completion{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return mo, nil
+}
+
+// completion carries the options of the complete action. With closeShort the
+// order is closed with what has been produced so far instead of posting the
+// remaining quantity; output identifies the units that are posted.
+type completion struct {
+	closeShort bool
+	output     types.ProductionOutput
+}
+
+// transitionMOTx moves an order along the state machine inside tx. done is
+// only consulted by the complete action.
+func (p *Postgres) transitionMOTx(tx *sql.Tx, id int, action string, actorID int, done completion) (*types.ManufacturingOrder, error) {
+	mo, err := scanMO(tx.QueryRow(`SELECT `+moColumns+` FROM manufacturing_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "manufacturing order with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+	}
+
+	var heldFrom string
+	if action == manufacturing.ActionResume {
+		err := tx.QueryRow(`
+			SELECT from_status FROM mo_transitions
+			WHERE mo_id = $1 AND to_status = 'on_hold'
+			ORDER BY id DESC LIMIT 1`, id).Scan(&heldFrom)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("could not fetch hold transition: %w", err)
+		}
+	}
+
+	to, err := manufacturing.Next(action, mo.Status, heldFrom)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.enterStatusTx(tx, &mo, to, actorID, done); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanMO(tx.QueryRow(`
+		UPDATE manufacturing_orders
+		SET status = $1, produced_quantity = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING `+moColumns, to, mo.ProducedQuantity, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not update manufacturing order status: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO mo_transitions (mo_id, action, from_status, to_status, actor_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0))`, id, action, mo.Status, to, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("could not record transition: %w", err)
+	}
+	return &updated, nil
+}
+
+// enterStatusTx checks the guards of moving mo into status `to` and applies
+// its side effects. actorID is recorded on any order it confirms in turn.
+func (p *Postgres) enterStatusTx(tx *sql.Tx, mo *types.ManufacturingOrder, to string, actorID int, done completion) error {
+	switch to {
+	case manufacturing.StatusConfirmed:
+		if !manufacturing.FirstEntry(mo.Status, to) {
+			break
+		}
+		var lines int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM bom
+			WHERE product_id = $1 AND version = (SELECT MAX(version) FROM bom WHERE product_id = $1)`, mo.ProductID).Scan(&lines)
+		if err != nil {
+			return fmt.Errorf("could not check bom: %w", err)
+		}
+		if lines == 0 {
+			return fmt.Errorf("%w: product %d has no bill of materials", manufacturing.ErrGuardFailed, mo.ProductID)
+		}
+		if err := p.generateWorkOrdersTx(tx, mo); err != nil {
+			return err
+		}
+		if err := linkWorkOrdersTx(tx, mo.ID); err != nil {
+			return err
+		}
+		if err := p.rankWorkOrdersTx(tx, mo); err != nil {
+			return err
+		}
+		components, err := p.reserveMaterialsTx(tx, mo)
+		if err != nil {
+			return err
+		}
+		if err := p.createChildMOsTx(tx, mo, components, actorID); err != nil {
+			return err
+		}
+
+	case manufacturing.StatusInProgress:
+		if !manufacturing.FirstEntry(mo.Status, to) {
+			break
+		}
+		var open int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM manufacturing_orders
+			WHERE parent_mo_id = $1 AND status NOT IN ('done', 'cancelled')`, mo.ID).Scan(&open)
+		if err != nil {
+			return fmt.Errorf("could not check sub-assembly orders: %w", err)
+		}
+		if open > 0 {
+			return fmt.Errorf("%w: %d sub-assembly orders are not done yet", manufacturing.ErrGuardFailed, open)
+		}
+
+	case manufacturing.StatusCancelled:
+		if err := p.releaseReservationsTx(tx, mo.ID); err != nil {
+			return err
+		}
+
+	case manufacturing.StatusDone:
+		var open int
+		err := tx.QueryRow("SELECT COUNT(*) FROM work_orders WHERE mo_id = $1 AND status <> 'completed'", mo.ID).Scan(&open)
+		if err != nil {
+			return fmt.Errorf("could not check work orders: %w", err)
+		}
+		if open > 0 {
+			return fmt.Errorf("%w: %d work orders are still open", manufacturing.ErrGuardFailed, open)
+		}
+		if done.closeShort {
+			if mo.ProducedQuantity == 0 {
+				return fmt.Errorf("%w: nothing has been produced, cancel the order instead", manufacturing.ErrGuardFailed)
+			}
+		} else if remaining := mo.Quantity - mo.ProducedQuantity - mo.ScrappedQuantity; remaining > 0 {
+			if err := p.produceTx(tx, mo, float64(remaining), done.output); err != nil {
+				return err
+			}
+			mo.ProducedQuantity += remaining
+		}
+		if err := p.releaseReservationsTx(tx, mo.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// routingWorkTx returns the work the routing of a product takes for
+// quantity units, in routing order.
+func routingWorkTx(tx *sql.Tx, productID, quantity int) ([]types.WorkStep, error) {
+	rows, err := tx.Query(`
+		SELECT work_center_id, setup_minutes + run_minutes_per_unit * $1
+		FROM routing_operations
+		WHERE product_id = $2
+		ORDER BY sequence ASC, id ASC`, quantity, productID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch routing of product %d: %w", productID, err)
+	}
+	defer rows.Close()
+
+	var steps []types.WorkStep
+	for rows.Next() {
+		var step types.WorkStep
+		if err := rows.Scan(&step.WorkCenterID, &step.Minutes); err != nil {
+			return nil, fmt.Errorf("could not scan routing operation: %w", err)
+		}
+		steps = append(steps, step)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return steps, nil
+}
+
+// createChildMOsTx raises a confirmed order for every sub-assembly the parent
+// is short of. Each child is due when the parent starts and starts early
+// enough to fit its routing into the working time of its work centers;
+// confirming it in turn covers its own shortages.
+func (p *Postgres) createChildMOsTx(tx *sql.Tx, parent *types.ManufacturingOrder, components []planning.ComponentAvailability, actorID int) error {
+	for _, c := range components {
+		if !c.SubAssembly || c.Shortage <= 1e-9 {
+			continue
+		}
+		quantity := int(math.Ceil(c.Shortage - 1e-9))
+
+		steps, err := routingWorkTx(tx, c.ComponentID, quantity)
+		if err != nil {
+			return err
+		}
+		due := parent.StartDate
+		timelines, err := workStepTimelines(tx, steps, due.AddDate(0, 0, -scheduleHorizon), due, time.Now())
+		if err != nil {
+			return err
+		}
+		// work that does not fit the horizon starts where it ends
+		start, _ := manufacturing.BackSchedule(due, steps, timelines)
+		start = midnight(start)
+
+		var childID int
+		err = tx.QueryRow(`
+			INSERT INTO manufacturing_orders (product_id, quantity, status, priority, start_date, due_date, assigned_manager_id, parent_mo_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			c.ComponentID, quantity, manufacturing.StatusDraft, parent.Priority, start, due, parent.AssignedManagerID, parent.ID).Scan(&childID)
+		if err != nil {
+			return fmt.Errorf("could not create sub-assembly order for product %d: %w", c.ComponentID, err)
+		}
+		if _, err := p.transitionMOTx(tx, childID, manufacturing.ActionConfirm, actorID, completion{}); err != nil {
+			return fmt.Errorf("could not confirm sub-assembly order %d: %w", childID, err)
+		}
+	}
+	return nil
+}
+
+// GetChildMOs returns the sub-assembly orders raised for an order.
+func (p *Postgres) GetChildMOs(parentID int) ([]types.ManufacturingOrder, error) {
+	query := `SELECT ` + moColumns + ` FROM manufacturing_orders WHERE parent_mo_id = $1 ORDER BY id ASC`
+
+	rows, err := p.db.Query(query, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch sub-assembly orders: %w", err)
+	}
+	defer rows.Close()
+
+	var mos []types.ManufacturingOrder
+	for rows.Next() {
+		mo, err := scanMO(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan manufacturing order: %w", err)
+		}
+		mos = append(mos, mo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return mos, nil
+}
+
+// ProduceMO reports quantity more units of a running order as produced and
+// posts their stock movements.
+func (p *Postgres) ProduceMO(id, quantity int, output types.ProductionOutput) (*types.ManufacturingOrder, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	mo, err := scanMO(tx.QueryRow(`SELECT `+moColumns+` FROM manufacturing_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "manufacturing order with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+	}
+	if mo.Status != manufacturing.StatusInProgress {
+		return nil, fmt.Errorf("%w: production is reported on running orders, this one is %s",
+			manufacturing.ErrGuardFailed, mo.Status)
+	}
+	if remaining := mo.Quantity - mo.ProducedQuantity - mo.ScrappedQuantity; quantity > remaining {
+		return nil, fmt.Errorf("%w: only %d of %d units are left to produce",
+			manufacturing.ErrGuardFailed, remaining, mo.Quantity)
+	}
+
+	if err := p.produceTx(tx, &mo, float64(quantity), output); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanMO(tx.QueryRow(`
+		UPDATE manufacturing_orders
+		SET produced_quantity = produced_quantity + $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+moColumns, quantity, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not update produced quantity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &updated, nil
+}
+
+// CompleteMO finishes an order. With closeShort it is closed at the quantity
+// produced so far, and with backorder a new draft order is raised for the
+// rest. The backorder is nil when none was created.
+func (p *Postgres) CompleteMO(id int, closeShort, backorder bool, output types.ProductionOutput, actorID int) (*types.ManufacturingOrder, *types.ManufacturingOrder, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	mo, err := p.transitionMOTx(tx, id, manufacturing.ActionComplete, actorID, completion{closeShort: closeShort, output: output})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var created *types.ManufacturingOrder
+	if closeShort && backorder && mo.ProducedQuantity+mo.ScrappedQuantity < mo.Quantity {
+		// The backorder starts today; a due date that has already passed
+		// is dropped rather than copied, so it never lands before the start.
+		originID := mo.ID
+		bo, err := scanMO(tx.QueryRow(`
+			INSERT INTO manufacturing_orders (product_id, quantity, status, priority, start_date, due_date, assigned_manager_id, origin_mo_id)
+			VALUES ($1, $2, $3, $4, CURRENT_DATE, CASE WHEN $5::date >= CURRENT_DATE THEN $5::date END, $6, $7)
+			RETURNING `+moColumns,
+			mo.ProductID, mo.Quantity-mo.ProducedQuantity-mo.ScrappedQuantity, manufacturing.StatusDraft, mo.Priority, mo.DueDate, mo.AssignedManagerID, originID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not create backorder: %w", err)
+		}
+		created = &bo
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return mo, created, nil
+}
+
+// SplitMO moves quantity units of a draft order into a new draft order, so
+// the two quantities add up to the original.
+func (p *Postgres) SplitMO(id, quantity int) (*types.ManufacturingOrder, *types.ManufacturingOrder, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	mo, err := scanMO(tx.QueryRow(`SELECT `+moColumns+` FROM manufacturing_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, apperr.Newf(apperr.NotFound, "manufacturing order with id %d not found", id)
+		}
+		return nil, nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+	}
+	if mo.Status != manufacturing.StatusDraft {
+		return nil, nil, manufacturing.ErrNotEditable
+	}
+	if quantity >= mo.Quantity {
+		return nil, nil, fmt.Errorf("%w: split quantity must be less than the order quantity %d",
+			manufacturing.ErrGuardFailed, mo.Quantity)
+	}
+
+	original, err := scanMO(tx.QueryRow(`
+		UPDATE manufacturing_orders
+		SET quantity = quantity - $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+moColumns, quantity, id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not update manufacturing order: %w", err)
+	}
+
+	split, err := scanMO(tx.QueryRow(`
+		INSERT INTO manufacturing_orders (product_id, quantity, status, priority, start_date, due_date, assigned_manager_id, origin_mo_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+moColumns,
+		mo.ProductID, quantity, manufacturing.StatusDraft, mo.Priority, mo.StartDate, mo.DueDate, mo.AssignedManagerID, mo.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create split order: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &original, &split, nil
+}
+
+func (p *Postgres) GetMOTransitions(moID int) ([]types.MOTransition, error) {
+	query := `
+		SELECT id, mo_id, action, from_status, to_status, actor_id, created_at
+		FROM mo_transitions
+		WHERE mo_id = $1
+		ORDER BY id ASC
+	`
+
+	rows, err := p.db.Query(query, moID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var history []types.MOTransition
+	for rows.Next() {
+		var t types.MOTransition
+		if err := rows.Scan(
+			&t.ID,
+			&t.MOID,
+			&t.Action,
+			&t.FromStatus,
+			&t.ToStatus,
+			&t.ActorID,
+			&t.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan transition: %w", err)
+		}
+		history = append(history, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return history, nil
+}
+
+//-----------------MO------------Radiator-------------------------//
+
+// -----------------work orders---Radiator-------------------------//
+const woColumns = `id, mo_id, step_name, status, start_time, end_time, assigned_worker_id, work_center_id,
+	sequence, operation_id, planned_minutes, queue_rank, actual_minutes, rework_of_id, created_at, updated_at`
+
+func scanWorkOrder(row rowScanner) (types.WorkOrder, error) {
+	var wo types.WorkOrder
+	err := row.Scan(
+		&wo.ID,
+		&wo.MOID,
+		&wo.StepName,
+		&wo.Status,
+		&wo.StartTime,
+		&wo.EndTime,
+		&wo.AssignedWorkerID,
+		&wo.WorkCenterID,
+		&wo.Sequence,
+		&wo.OperationID,
+		&wo.PlannedMinutes,
+		&wo.QueueRank,
+		&wo.ActualMinutes,
+		&wo.ReworkOfID,
+		&wo.CreatedAt,
+		&wo.UpdatedAt,
+	)
+	return wo, err
+}
+
+// generateWorkOrdersTx creates the work orders of a confirmed order: one per
+// routing operation, or one per distinct BoM operation name when the product
+// has no routing. Planned time is setup plus run time for the order quantity,
+// taken from the routing operation or from the BoM lines naming the step.
+func (p *Postgres) generateWorkOrdersTx(tx *sql.Tx, mo *types.ManufacturingOrder) error {
+	var existing int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM work_orders WHERE mo_id = $1", mo.ID).Scan(&existing); err != nil {
+		return fmt.Errorf("could not check work orders: %w", err)
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO work_orders (mo_id, step_name, status, work_center_id, sequence, operation_id, planned_minutes)
+		SELECT $1, name, 'pending', work_center_id, sequence, id, setup_minutes + run_minutes_per_unit * $2
+		FROM routing_operations
+		WHERE product_id = $3
+		ORDER BY sequence ASC, id ASC`, mo.ID, mo.Quantity, mo.ProductID)
+	if err != nil {
+		return fmt.Errorf("could not generate work orders from routing: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	// no routing: fall back to the operations named on the BoM, in the order
+	// they were first entered, with the work center and times given there
+	_, err = tx.Exec(`
+		INSERT INTO work_orders (mo_id, step_name, status, work_center_id, sequence, planned_minutes)
+		SELECT $1, op.operation_name, 'pending', op.work_center_id,
+		       ROW_NUMBER() OVER (ORDER BY op.first_id) * 10,
+		       op.setup_minutes + op.run_minutes_per_unit * $3
+		FROM (
+			SELECT DISTINCT ON (operation_name) operation_name, id AS first_id,
+			       work_center_id, setup_minutes, run_minutes_per_unit
+			FROM bom
+			WHERE product_id = $2
+			  AND operation_name IS NOT NULL AND operation_name <> ''
+			  AND version = (SELECT MAX(version) FROM bom WHERE product_id = $2)
+			ORDER BY operation_name, id ASC
+		) op`, mo.ID, mo.ProductID, mo.Quantity)
+	if err != nil {
+		return fmt.Errorf("could not generate work orders from bom: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetWorkOrdersByMO(moID int) ([]types.WorkOrder, error) {
+	query := `
+		SELECT ` + woColumns + `
+		FROM work_orders
+		WHERE mo_id = $1
+		ORDER BY sequence ASC, id ASC
+	`
+
+	rows, err := p.db.Query(query, moID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch work orders: %w", err)
+	}
+	defer rows.Close()
+
+	var wos []types.WorkOrder
+	for rows.Next() {
+		wo, err := scanWorkOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan work order: %w", err)
+		}
+		wos = append(wos, wo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return wos, nil
+}
+
+// queueLock namespaces the per-work-center advisory locks taken while
+// queue ranks are assigned.
+const queueLock = 3403
+
+// openQueue restricts work orders to those still waiting or running.
+const openQueue = `work_center_id = $1 AND status <> 'completed' AND queue_rank IS NOT NULL`
+
+// placeWorkOrderTx gives a work order a rank between the neighbours returned
+// by `neighbours`, renumbering the work center's queue when they are too
+// close together.
+func placeWorkOrderTx(tx *sql.Tx, woID, workCenterID int, neighbours func() (prev, next *float64, err error)) error {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, $2)", queueLock, workCenterID); err != nil {
+		return fmt.Errorf("could not lock queue of work center %d: %w", workCenterID, err)
+	}
+
+	prev, next, err := neighbours()
+	if err != nil {
+		return err
+	}
+	rank, ok := manufacturing.RankBetween(prev, next)
+	if !ok {
+		_, err := tx.Exec(`
+			UPDATE work_orders w
+			SET queue_rank = q.rn * $2
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY queue_rank ASC, id ASC) AS rn
+				FROM work_orders
+				WHERE `+openQueue+`
+			) q
+			WHERE w.id = q.id`, workCenterID, manufacturing.RankGap)
+		if err != nil {
+			return fmt.Errorf("could not renumber queue: %w", err)
+		}
+		if prev, next, err = neighbours(); err != nil {
+			return err
+		}
+		rank, _ = manufacturing.RankBetween(prev, next)
+	}
+
+	_, err = tx.Exec("UPDATE work_orders SET queue_rank = $1, updated_at = NOW() WHERE id = $2", rank, woID)
+	if err != nil {
+		return fmt.Errorf("could not rank work order: %w", err)
+	}
+	return nil
+}
+
+func nullRank(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+// rankWorkOrdersTx queues the unranked work orders of an order at their work
+// centers: behind every open work order of an order with the same or higher
+// priority, ahead of those with a lower one.
+func (p *Postgres) rankWorkOrdersTx(tx *sql.Tx, mo *types.ManufacturingOrder) error {
+	rows, err := tx.Query(`
+		SELECT id, work_center_id FROM work_orders
+		WHERE mo_id = $1 AND queue_rank IS NULL AND work_center_id IS NOT NULL
+		ORDER BY sequence ASC, id ASC`, mo.ID)
+	if err != nil {
+		return fmt.Errorf("could not fetch work orders: %w", err)
+	}
+	type unranked struct{ id, workCenterID int }
+	var wos []unranked
+	for rows.Next() {
+		var u unranked
+		if err := rows.Scan(&u.id, &u.workCenterID); err != nil {
+			rows.Close()
+			return fmt.Errorf("could not scan work order: %w", err)
+		}
+		wos = append(wos, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, wo := range wos {
+		neighbours := func() (*float64, *float64, error) {
+			var prev, next sql.NullFloat64
+			err := tx.QueryRow(`
+				SELECT MAX(w.queue_rank)
+				FROM work_orders w
+				JOIN manufacturing_orders m ON m.id = w.mo_id
+				WHERE w.work_center_id = $1 AND w.status <> 'completed' AND w.queue_rank IS NOT NULL
+				  AND m.priority >= $2`, wo.workCenterID, mo.Priority).Scan(&prev)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not fetch queue position: %w", err)
+			}
+			err = tx.QueryRow(`
+				SELECT MIN(queue_rank) FROM work_orders
+				WHERE `+openQueue+` AND ($2::float8 IS NULL OR queue_rank > $2::float8)`,
+				wo.workCenterID, prev).Scan(&next)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not fetch queue position: %w", err)
+			}
+			return nullRank(prev), nullRank(next), nil
+		}
+		if err := placeWorkOrderTx(tx, wo.id, wo.workCenterID, neighbours); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReorderWorkOrder moves a work order within its work center queue to just
+// after afterID or just before beforeID; with neither it goes to the front.
+func (p *Postgres) ReorderWorkOrder(id int, afterID, beforeID *int) (*types.WorkOrder, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	wo, err := scanWorkOrder(tx.QueryRow(`SELECT `+woColumns+` FROM work_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	if wo.WorkCenterID == nil {
+		return nil, fmt.Errorf("%w: work order %d has no work center", manufacturing.ErrGuardFailed, id)
+	}
+	if wo.Status == "completed" {
+		return nil, fmt.Errorf("%w: work order %d is completed", manufacturing.ErrGuardFailed, id)
+	}
+	wcID := *wo.WorkCenterID
+
+	// anchorRank is the rank of the neighbour the work order is dropped next to
+	anchorRank := func(anchorID int) (*float64, error) {
+		var rank sql.NullFloat64
+		err := tx.QueryRow(`SELECT queue_rank FROM work_orders WHERE `+openQueue+` AND id = $2`,
+			wcID, anchorID).Scan(&rank)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found in the queue of work center %d", anchorID, wcID)
+			}
+			return nil, fmt.Errorf("could not fetch queue position: %w", err)
+		}
+		return nullRank(rank), nil
+	}
+	neighbours := func() (*float64, *float64, error) {
+		var prev, next sql.NullFloat64
+		switch {
+		case afterID != nil:
+			rank, err := anchorRank(*afterID)
+			if err != nil {
+				return nil, nil, err
+			}
+			err = tx.QueryRow(`
+				SELECT MIN(queue_rank) FROM work_orders
+				WHERE `+openQueue+` AND queue_rank > $2 AND id <> $3`, wcID, *rank, id).Scan(&next)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not fetch queue position: %w", err)
+			}
+			return rank, nullRank(next), nil
+		case beforeID != nil:
+			rank, err := anchorRank(*beforeID)
+			if err != nil {
+				return nil, nil, err
+			}
+			err = tx.QueryRow(`
+				SELECT MAX(queue_rank) FROM work_orders
+				WHERE `+openQueue+` AND queue_rank < $2 AND id <> $3`, wcID, *rank, id).Scan(&prev)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not fetch queue position: %w", err)
+			}
+			return nullRank(prev), rank, nil
+		}
+		err := tx.QueryRow(`SELECT MIN(queue_rank) FROM work_orders WHERE `+openQueue+` AND id <> $2`,
+			wcID, id).Scan(&next)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not fetch queue position: %w", err)
+		}
+		return nil, nullRank(next), nil
+	}
+
+	if err := placeWorkOrderTx(tx, id, wcID, neighbours); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanWorkOrder(tx.QueryRow(`SELECT `+woColumns+` FROM work_orders WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &updated, nil
+}
+
+// GetWorkCenterQueue returns the open work orders of a work center in queue
+// order: by rank, then by the due date of their order. Each is given the
+// time it is planned to run, one after another from now; work beyond the
+// scheduling horizon is left without.
+func (p *Postgres) GetWorkCenterQueue(workCenterID int) ([]types.QueueEntry, error) {
+	var exists bool
+	err := p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM work_centers WHERE id = $1)", workCenterID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error checking work center existence: %w", err)
+	}
+	if !exists {
+		return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", workCenterID)
+	}
+
+	query := `
+		SELECT w.id, w.mo_id, w.step_name, w.status, w.start_time, w.end_time, w.assigned_worker_id,
+		       w.work_center_id, w.sequence, w.operation_id, w.planned_minutes, w.queue_rank, w.actual_minutes, w.rework_of_id,
+		       w.created_at, w.updated_at, m.product_id, m.priority, m.due_date
+		FROM work_orders w
+		JOIN manufacturing_orders m ON m.id = w.mo_id
+		WHERE w.work_center_id = $1
+		  AND w.status <> 'completed'
+		  AND m.status NOT IN ('done', 'cancelled')
+		ORDER BY w.queue_rank ASC NULLS LAST, m.due_date ASC NULLS LAST, w.id ASC
+	`
+
+	rows, err := p.db.Query(query, workCenterID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch queue: %w", err)
+	}
+	defer rows.Close()
+
+	queue := []types.QueueEntry{}
+	for rows.Next() {
+		var e types.QueueEntry
+		err := rows.Scan(
+			&e.ID,
+			&e.MOID,
+			&e.StepName,
+			&e.Status,
+			&e.StartTime,
+			&e.EndTime,
+			&e.AssignedWorkerID,
+			&e.WorkCenterID,
+			&e.Sequence,
+			&e.OperationID,
+			&e.PlannedMinutes,
+			&e.QueueRank,
+			&e.ActualMinutes,
+			&e.ReworkOfID,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+			&e.ProductID,
+			&e.MOPriority,
+			&e.DueDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan queue entry: %w", err)
+		}
+		queue = append(queue, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	now := time.Now()
+	timeline, err := workCenterTimeline(p.db, &workCenterID, now, now.AddDate(0, 0, scheduleHorizon), now)
+	if err != nil {
+		return nil, err
+	}
+	at := now
+	for i := range queue {
+		start, ok := timeline.Next(at)
+		if !ok {
+			break
+		}
+		end, ok := timeline.Finish(start, math.Max(queue[i].PlannedMinutes-queue[i].ActualMinutes, 0))
+		if !ok {
+			break
+		}
+		queue[i].PlannedStart, queue[i].PlannedEnd = &start, &end
+		at = end
+	}
+
+	return queue, nil
+}
+
+func (p *Postgres) GetWorkOrderByID(id int) (*types.WorkOrder, error) {
+	query := `SELECT ` + woColumns + ` FROM work_orders WHERE id = $1`
+
+	wo, err := scanWorkOrder(p.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	return &wo, nil
+}
+
+// WorkOrderAction starts, pauses, resumes or completes a work order on behalf
+// of actorID. Workers may only act on work orders assigned to them, and the
+// first to start an unassigned one takes it; managers may act on any. The
+// first start of an order's work moves the order to in_progress.
+func (p *Postgres) WorkOrderAction(id int, action string, actorID int, isManager bool) (*types.WorkOrder, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	wo, err := scanWorkOrder(tx.QueryRow(`SELECT `+woColumns+` FROM work_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+
+	if wo.AssignedWorkerID != nil && *wo.AssignedWorkerID != actorID && !isManager {
+		return nil, manufacturing.ErrNotAssigned
+	}
+	if wo.AssignedWorkerID == nil && action == manufacturing.WOActionStart && !isManager {
+		if err := checkQualifiedTx(tx, id, actorID); err != nil {
+			return nil, err
+		}
+		wo.AssignedWorkerID = &actorID
+	}
+
+	to, err := manufacturing.NextWO(action, wo.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	if action == manufacturing.WOActionStart {
+		var waiting []int64
+		err := tx.QueryRow(`
+			SELECT COALESCE(array_agg(p.id ORDER BY p.id), '{}')
+			FROM work_order_dependencies d
+			JOIN work_orders p ON p.id = d.predecessor_id
+			WHERE d.work_order_id = $1 AND p.status <> 'completed'`, id).Scan(pq.Array(&waiting))
+		if err != nil {
+			return nil, fmt.Errorf("could not check predecessors: %w", err)
+		}
+		if len(waiting) > 0 {
+			return nil, fmt.Errorf("%w: waiting for work orders %v to be completed", manufacturing.ErrGuardFailed, waiting)
+		}
+	}
+
+	if to == manufacturing.WOInProgress {
+		var moStatus string
+		if err := tx.QueryRow("SELECT status FROM manufacturing_orders WHERE id = $1", wo.MOID).Scan(&moStatus); err != nil {
+			return nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+		}
+		switch moStatus {
+		case manufacturing.StatusConfirmed:
+			if _, err := p.transitionMOTx(tx, wo.MOID, manufacturing.ActionStart, actorID, completion{}); err != nil {
+				return nil, err
+			}
+		case manufacturing.StatusInProgress:
+		default:
+			return nil, fmt.Errorf("%w: manufacturing order %d is %s", manufacturing.ErrGuardFailed, wo.MOID, moStatus)
+		}
+
+		if wo.WorkCenterID != nil {
+			var wcStatus string
+			if err := tx.QueryRow("SELECT status FROM work_centers WHERE id = $1", *wo.WorkCenterID).Scan(&wcStatus); err != nil {
+				return nil, fmt.Errorf("could not fetch work center: %w", err)
+			}
+			if wcStatus != manufacturing.WorkCenterAvailable {
+				return nil, fmt.Errorf("%w: work center %d is %s", manufacturing.ErrGuardFailed, *wo.WorkCenterID, wcStatus)
+			}
+
+			// the work left must not run into planned maintenance, and
+			// maintenance that is overdue blocks the center until it is done
+			now := time.Now()
+			windows, err := workCenterWindows(tx, wo.WorkCenterID, now, now.AddDate(0, 0, scheduleHorizon))
+			if err != nil {
+				return nil, err
+			}
+			finish, _ := calendar.NewTimeline(windows, nil).Finish(now, math.Max(wo.PlannedMinutes-wo.ActualMinutes, 0))
+
+			var blocked bool
+			err = tx.QueryRow(`
+				SELECT EXISTS (
+					SELECT 1 FROM maintenance_tasks
+					WHERE work_center_id = $1 AND status = 'planned' AND due_at <= $2
+				)`, *wo.WorkCenterID, finish).Scan(&blocked)
+			if err != nil {
+				return nil, fmt.Errorf("could not check planned maintenance: %w", err)
+			}
+			if blocked {
+				return nil, fmt.Errorf("%w: work center %d is reserved for planned maintenance", manufacturing.ErrGuardFailed, *wo.WorkCenterID)
+			}
+		}
+
+		// sessions are booked to the worker doing the work, also when a
+		// manager starts it for them
+		workerID := actorID
+		if wo.AssignedWorkerID != nil {
+			workerID = *wo.AssignedWorkerID
+			if err := checkSkillsTx(tx, id, workerID); err != nil {
+				return nil, err
+			}
+		}
+		_, err := tx.Exec(`INSERT INTO work_order_time_logs (work_order_id, worker_id) VALUES ($1, $2)`, id, workerID)
+		if err != nil {
+			return nil, fmt.Errorf("could not open time log: %w", err)
+		}
+		// the worker's timesheet runs with the session; pausing or
+		// completing clocks everyone out below
+		if _, err := clockInTx(tx, id, workerID); err != nil {
+			return nil, err
+		}
+	} else {
+		_, err := tx.Exec(`
+			UPDATE work_order_time_logs
+			SET ended_at = NOW(), updated_at = NOW()
+			WHERE work_order_id = $1 AND ended_at IS NULL`, id)
+		if err != nil {
+			return nil, fmt.Errorf("could not close time log: %w", err)
+		}
+		// nobody stays clocked in on work that has stopped
+		_, err = tx.Exec(`
+			UPDATE labour_entries
+			SET clock_out = NOW(), updated_at = NOW()
+			WHERE work_order_id = $1 AND clock_out IS NULL`, id)
+		if err != nil {
+			return nil, fmt.Errorf("could not clock out workers: %w", err)
+		}
+
+		// the closed session may bring run-hour maintenance due
+		if wo.WorkCenterID != nil {
+			if _, err := generateMaintenanceTasksTx(tx, wo.WorkCenterID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	updated, err := scanWorkOrder(tx.QueryRow(`
+		UPDATE work_orders
+		SET status = $1,
+		    assigned_worker_id = $2,
+		    start_time = CASE WHEN $1 = 'in_progress' THEN COALESCE(start_time, NOW()) ELSE start_time END,
+		    end_time = CASE WHEN $1 = 'completed' THEN NOW() ELSE end_time END,
+		    actual_minutes = (
+		        SELECT COALESCE(SUM(EXTRACT(EPOCH FROM ended_at - started_at)) / 60, 0)
+		        FROM work_order_time_logs
+		        WHERE work_order_id = $3 AND ended_at IS NOT NULL
+		    ),
+		    updated_at = NOW()
+		WHERE id = $3
+		RETURNING `+woColumns, to, wo.AssignedWorkerID, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not update work order: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &updated, nil
+}
+
+func (p *Postgres) GetWorkOrderTimeLogs(workOrderID int) ([]types.WorkOrderTimeLog, error) {
+	query := `
+		SELECT id, work_order_id, worker_id, started_at, ended_at, created_at, updated_at
+		FROM work_order_time_logs
+		WHERE work_order_id = $1
+		ORDER BY id ASC
+	`
+
+	rows, err := p.db.Query(query, workOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch time logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []types.WorkOrderTimeLog
+	for rows.Next() {
+		var l types.WorkOrderTimeLog
+		if err := rows.Scan(&l.ID, &l.WorkOrderID, &l.WorkerID, &l.StartedAt, &l.EndedAt, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan time log: %w", err)
+		}
+		logs = append(logs, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return logs, nil
+}
+
+// GetWorkerTasks returns what a worker can do next: open work orders
+// assigned to them and unassigned ones at the work centers they are
+// qualified for and hold the required certifications for, most urgent
+// order first, then by due date.
+func (p *Postgres) GetWorkerTasks(workerID int) ([]types.Task, error) {
+	query := `
+		SELECT w.id, w.mo_id, w.step_name, w.status, w.start_time, w.end_time, w.assigned_worker_id,
+		       w.work_center_id, w.sequence, w.operation_id, w.planned_minutes, w.queue_rank, w.actual_minutes, w.rework_of_id,
+		       w.created_at, w.updated_at, m.product_id, m.priority, m.due_date,
+		       pr.name, COALESCE(r.instructions, '')
+		FROM work_orders w
+		JOIN manufacturing_orders m ON m.id = w.mo_id
+		JOIN products pr ON pr.id = m.product_id
+		LEFT JOIN routing_operations r ON r.id = w.operation_id
+		WHERE w.status <> 'completed'
+		  AND m.status IN ('confirmed', 'in_progress')
+		  AND (w.assigned_worker_id = $1
+		       OR (w.assigned_worker_id IS NULL AND ` + qualifiedSQL + `
+		           AND NOT EXISTS (
+		               SELECT 1
+		               FROM (SELECT skill_id FROM work_center_skills WHERE work_center_id = w.work_center_id
+		                     UNION
+		                     SELECT skill_id FROM operation_skills WHERE operation_id = w.operation_id) req
+		               WHERE NOT EXISTS (
+		                   SELECT 1 FROM user_skills u
+		                   WHERE u.user_id = $1 AND u.skill_id = req.skill_id
+		                     AND (u.expires_at IS NULL OR u.expires_at >= CURRENT_DATE)))))
+		ORDER BY m.priority DESC, m.due_date ASC NULLS LAST, w.queue_rank ASC NULLS LAST, w.id ASC
+	`
+
+	rows, err := p.db.Query(query, workerID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch work orders: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []types.Task{}
+	for rows.Next() {
+		var t types.Task
+		err := rows.Scan(
+			&t.ID,
+			&t.MOID,
+			&t.StepName,
+			&t.Status,
+			&t.StartTime,
+			&t.EndTime,
+			&t.AssignedWorkerID,
+			&t.WorkCenterID,
+			&t.Sequence,
+			&t.OperationID,
+			&t.PlannedMinutes,
+			&t.QueueRank,
+			&t.ActualMinutes,
+			&t.ReworkOfID,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+			&t.ProductID,
+			&t.MOPriority,
+			&t.DueDate,
+			&t.ProductName,
+			&t.Instructions,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan work order: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	for i := range tasks {
+		components, err := p.workOrderComponents(tasks[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		tasks[i].Components = components
+	}
+	return tasks, nil
+}
+
+// workOrderComponents returns the components consumed at a work order's
+// step: the gross requirements (scrap and yield included) linked to its
+// routing operation, or for work orders generated without a routing, those
+// carrying its operation name. Requirements covered by reserved substitutes
+// list the substitute, as backflush would issue it.
+func (p *Postgres) workOrderComponents(workOrderID int) ([]types.TaskComponent, error) {
+	var (
+		moID, productID, quantity int
+		operationID               *int
+		stepName                  string
+	)
+	err := p.db.QueryRow(`
+		SELECT m.id, m.product_id, m.quantity, w.operation_id, w.step_name
+		FROM work_orders w
+		JOIN manufacturing_orders m ON m.id = w.mo_id
+		WHERE w.id = $1`, workOrderID).Scan(&moID, &productID, &quantity, &operationID, &stepName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", workOrderID)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+
+	tree, err := bom.Explode(bomSource{p.db}, productID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	components := []types.TaskComponent{}
+	index := map[int]int{}
+	add := func(id int, name string, qty float64) {
+		if i, ok := index[id]; ok {
+			components[i].Quantity += qty
+			return
+		}
+		index[id] = len(components)
+		components = append(components, types.TaskComponent{ComponentID: id, ComponentName: name, Quantity: qty})
+	}
+
+	for _, req := range tree.Requirements() {
+		atStep := req.OperationID != nil && operationID != nil && *req.OperationID == *operationID
+		if operationID == nil {
+			atStep = req.OperationName == stepName
+		}
+		if !atStep {
+			continue
+		}
+
+		need := req.Quantity * float64(quantity)
+		rows, err := p.db.Query(`
+			SELECT r.component_id, c.name, r.quantity, COALESCE(a.conversion_ratio, 1)
+			FROM material_reservations r
+			JOIN products c ON c.id = r.component_id
+			LEFT JOIN bom_alternates a ON a.id = r.alternate_id
+			WHERE r.mo_id = $1 AND r.bom_id = $2 AND r.status = 'active'
+			ORDER BY r.substitute ASC, r.id ASC`, moID, req.BoMID)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch reservations: %w", err)
+		}
+		for rows.Next() {
+			var (
+				id          int
+				name        string
+				units, rate float64
+			)
+			if err := rows.Scan(&id, &name, &units, &rate); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("could not scan reservation: %w", err)
+			}
+			covered := min(units/rate, need)
+			if covered > 1e-9 {
+				add(id, name, covered*rate)
+				need -= covered
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("rows error: %w", err)
+		}
+		rows.Close()
+
+		if need > 1e-9 {
+			add(req.ComponentID, req.ComponentName, need)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i].ComponentName < components[j].ComponentName })
+	return components, nil
+}
+
+func (p *Postgres) AddQualification(workerID, workCenterID int) (*types.WorkerQualification, error) {
+	var exists bool
+	err := p.db.QueryRow("SELECT EXISTS(SELECT 1 FROM work_centers WHERE id = $1)", workCenterID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error checking work center existence: %w", err)
+	}
+	if !exists {
+		return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", workCenterID)
+	}
+
+	query := `
+		INSERT INTO worker_qualifications (worker_id, work_center_id)
+		VALUES ($1, $2)
+		ON CONFLICT (worker_id, work_center_id) DO UPDATE SET worker_id = EXCLUDED.worker_id
+		RETURNING id, worker_id, work_center_id,
+		          (SELECT name FROM work_centers WHERE id = $2), created_at
+	`
+
+	var q types.WorkerQualification
+	err = p.db.QueryRow(query, workerID, workCenterID).Scan(&q.ID, &q.WorkerID, &q.WorkCenterID, &q.WorkCenterName, &q.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not add qualification: %w", err)
+	}
+	return &q, nil
+}
+
+func (p *Postgres) GetQualifications(workerID int) ([]types.WorkerQualification, error) {
+	query := `
+		SELECT q.id, q.worker_id, q.work_center_id, wc.name, q.created_at
+		FROM worker_qualifications q
+		JOIN work_centers wc ON wc.id = q.work_center_id
+		WHERE q.worker_id = $1
+		ORDER BY wc.name ASC
+	`
+
+	rows, err := p.db.Query(query, workerID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch qualifications: %w", err)
+	}
+	defer rows.Close()
+
+	var qualifications []types.WorkerQualification
+	for rows.Next() {
+		var q types.WorkerQualification
+		if err := rows.Scan(&q.ID, &q.WorkerID, &q.WorkCenterID, &q.WorkCenterName, &q.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan qualification: %w", err)
+		}
+		qualifications = append(qualifications, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return qualifications, nil
+}
+
+func (p *Postgres) DeleteQualification(workerID, workCenterID int) error {
+	res, err := p.db.Exec("DELETE FROM worker_qualifications WHERE worker_id = $1 AND work_center_id = $2",
+		workerID, workCenterID)
+	if err != nil {
+		return fmt.Errorf("could not delete qualification: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperr.Newf(apperr.NotFound, "qualification of user %d for work center %d not found", workerID, workCenterID)
+	}
+	return nil
+}
+
+// linkWorkOrdersTx gives the work orders of an order their default
+// finish-to-start dependencies: each waits for every work order of the
+// closest lower sequence. Work orders sharing a sequence run in parallel and
+// all wait on the same predecessors.
+func linkWorkOrdersTx(tx *sql.Tx, moID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO work_order_dependencies (work_order_id, predecessor_id)
+		SELECT w.id, p.id
+		FROM work_orders w
+		JOIN work_orders p ON p.mo_id = w.mo_id
+		 AND p.sequence = (SELECT MAX(x.sequence) FROM work_orders x WHERE x.mo_id = w.mo_id AND x.sequence < w.sequence)
+		WHERE w.mo_id = $1
+		ON CONFLICT (work_order_id, predecessor_id) DO NOTHING`, moID)
+	if err != nil {
+		return fmt.Errorf("could not link work orders: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetMODependencies(moID int) ([]types.WorkOrderDependency, error) {
+	query := `
+		SELECT d.id, d.work_order_id, d.predecessor_id, d.created_at
+		FROM work_order_dependencies d
+		JOIN work_orders w ON w.id = d.work_order_id
+		WHERE w.mo_id = $1
+		ORDER BY d.work_order_id ASC, d.predecessor_id ASC
+	`
+
+	rows, err := p.db.Query(query, moID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	var deps []types.WorkOrderDependency
+	for rows.Next() {
+		var d types.WorkOrderDependency
+		if err := rows.Scan(&d.ID, &d.WorkOrderID, &d.PredecessorID, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan dependency: %w", err)
+		}
+		deps = append(deps, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return deps, nil
+}
+
+// AddDependency makes a work order wait for another of the same order. It is
+// refused once the work order has started or when it would close a loop.
+func (p *Postgres) AddDependency(workOrderID, predecessorID int) (*types.WorkOrderDependency, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	wo, err := scanWorkOrder(tx.QueryRow(`SELECT `+woColumns+` FROM work_orders WHERE id = $1 FOR UPDATE`, workOrderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", workOrderID)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	if wo.Status != manufacturing.WOPending {
+		return nil, fmt.Errorf("%w: work order %d has already started", manufacturing.ErrGuardFailed, workOrderID)
+	}
+
+	var predMO int
+	if err := tx.QueryRow("SELECT mo_id FROM work_orders WHERE id = $1", predecessorID).Scan(&predMO); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", predecessorID)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	if predMO != wo.MOID {
+		return nil, fmt.Errorf("%w: work orders %d and %d belong to different orders",
+			manufacturing.ErrGuardFailed, workOrderID, predecessorID)
+	}
+
+	// the order's graph is only changed under the order's row lock
+	if _, err := tx.Exec("SELECT id FROM manufacturing_orders WHERE id = $1 FOR UPDATE", wo.MOID); err != nil {
+		return nil, fmt.Errorf("could not lock manufacturing order: %w", err)
+	}
+	rows, err := tx.Query(`
+		SELECT d.work_order_id, d.predecessor_id
+		FROM work_order_dependencies d
+		JOIN work_orders w ON w.id = d.work_order_id
+		WHERE w.mo_id = $1`, wo.MOID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch dependencies: %w", err)
+	}
+	graph := manufacturing.Dependencies{}
+	for rows.Next() {
+		var from, pred int
+		if err := rows.Scan(&from, &pred); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan dependency: %w", err)
+		}
+		graph[from] = append(graph[from], pred)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	if graph.DependsOn(predecessorID, workOrderID) {
+		return nil, fmt.Errorf("%w: work order %d already waits for %d", manufacturing.ErrGuardFailed, predecessorID, workOrderID)
+	}
+
+	var d types.WorkOrderDependency
+	err = tx.QueryRow(`
+		INSERT INTO work_order_dependencies (work_order_id, predecessor_id)
+		VALUES ($1, $2)
+		ON CONFLICT (work_order_id, predecessor_id) DO UPDATE SET predecessor_id = EXCLUDED.predecessor_id
+		RETURNING id, work_order_id, predecessor_id, created_at`, workOrderID, predecessorID).
+		Scan(&d.ID, &d.WorkOrderID, &d.PredecessorID, &d.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not add dependency: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &d, nil
+}
+
+func (p *Postgres) DeleteDependency(workOrderID, predecessorID int) error {
+	res, err := p.db.Exec("DELETE FROM work_order_dependencies WHERE work_order_id = $1 AND predecessor_id = $2",
+		workOrderID, predecessorID)
+	if err != nil {
+		return fmt.Errorf("could not delete dependency: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperr.Newf(apperr.NotFound, "dependency of work order %d on %d not found", workOrderID, predecessorID)
+	}
+	return nil
+}
+
+const labourColumns = `id, work_order_id, worker_id, clock_in, clock_out,
+	EXTRACT(EPOCH FROM COALESCE(clock_out, NOW()) - clock_in) / 60, created_at, updated_at`
+
+func scanLabourEntry(row rowScanner) (types.LabourEntry, error) {
+	var e types.LabourEntry
+	err := row.Scan(&e.ID, &e.WorkOrderID, &e.WorkerID, &e.ClockIn, &e.ClockOut, &e.Minutes, &e.CreatedAt, &e.UpdatedAt)
+	return e, err
+}
+
+// ClockIn books a worker onto a running work order. A worker is clocked in
+// on one work order at a time.
+func (p *Postgres) ClockIn(workOrderID, workerID int) (*types.LabourEntry, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow("SELECT status FROM work_orders WHERE id = $1 FOR UPDATE", workOrderID).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", workOrderID)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	if status != manufacturing.WOInProgress {
+		return nil, fmt.Errorf("%w: work order %d is %s", manufacturing.ErrGuardFailed, workOrderID, status)
+	}
+	if err := checkSkillsTx(tx, workOrderID, workerID); err != nil {
+		return nil, err
+	}
+
+	entry, err := clockInTx(tx, workOrderID, workerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return entry, nil
+}
+
+// clockInTx opens a labour entry; a worker is clocked in on one work order
+// at a time.
+func clockInTx(tx *sql.Tx, workOrderID, workerID int) (*types.LabourEntry, error) {
+	var openOn int
+	err := tx.QueryRow("SELECT work_order_id FROM labour_entries WHERE worker_id = $1 AND clock_out IS NULL", workerID).Scan(&openOn)
+	if err == nil {
+		return nil, fmt.Errorf("%w: worker %d is still clocked in on work order %d", manufacturing.ErrGuardFailed, workerID, openOn)
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("could not check open labour entries: %w", err)
+	}
+
+	entry, err := scanLabourEntry(tx.QueryRow(`
+		INSERT INTO labour_entries (work_order_id, worker_id)
+		VALUES ($1, $2)
+		RETURNING `+labourColumns, workOrderID, workerID))
+	if err != nil {
+		return nil, fmt.Errorf("could not clock in: %w", err)
+	}
+	return &entry, nil
+}
+
+func (p *Postgres) ClockOut(workOrderID, workerID int) (*types.LabourEntry, error) {
+	query := `
+		UPDATE labour_entries
+		SET clock_out = NOW(), updated_at = NOW()
+		WHERE work_order_id = $1 AND worker_id = $2 AND clock_out IS NULL
+		RETURNING ` + labourColumns
+
+	entry, err := scanLabourEntry(p.db.QueryRow(query, workOrderID, workerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "open labour entry for worker %d on work order %d not found", workerID, workOrderID)
+		}
+		return nil, fmt.Errorf("could not clock out: %w", err)
+	}
+	return &entry, nil
+}
+
+// GetWorkOrderLabour returns the labour booked on a work order next to its
+// planned and actual run time. Entries still open count up to now.
+func (p *Postgres) GetWorkOrderLabour(workOrderID int) (*types.WorkOrderLabour, error) {
+	wo, err := p.GetWorkOrderByID(workOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.Query(`SELECT `+labourColumns+` FROM labour_entries WHERE work_order_id = $1 ORDER BY clock_in ASC, id ASC`, workOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch labour entries: %w", err)
+	}
+	defer rows.Close()
+
+	labour := &types.WorkOrderLabour{
+		WorkOrderID:    wo.ID,
+		PlannedMinutes: wo.PlannedMinutes,
+		ActualMinutes:  wo.ActualMinutes,
+		Entries:        []types.LabourEntry{},
+	}
+	for rows.Next() {
+		e, err := scanLabourEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan labour entry: %w", err)
+		}
+		labour.LabourMinutes += e.Minutes
+		labour.Entries = append(labour.Entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	labour.Variance = labour.ActualMinutes - labour.PlannedMinutes
+	return labour, nil
+}
+
+// GetTimesheet sums a worker's labour per work order per day or week
+// (period "day" or "week") for entries clocked in within [from, to).
+// An entry counts in full towards the period it was clocked in.
+func (p *Postgres) GetTimesheet(workerID int, period string, from, to time.Time) ([]types.TimesheetLine, error) {
+	query := `
+		SELECT date_trunc($2::text, l.clock_in), l.work_order_id, w.mo_id, w.step_name, COALESCE(pr.name, ''), COUNT(*),
+		       SUM(EXTRACT(EPOCH FROM COALESCE(l.clock_out, NOW()) - l.clock_in)) / 60
+		FROM labour_entries l
+		JOIN work_orders w ON w.id = l.work_order_id
+		JOIN manufacturing_orders m ON m.id = w.mo_id
+		LEFT JOIN products pr ON pr.id = m.product_id
+		WHERE l.worker_id = $1 AND l.clock_in >= $3 AND l.clock_in < $4
+		GROUP BY 1, 2, 3, 4, 5
+		ORDER BY 1 ASC, 2 ASC
+	`
+
+	rows, err := p.db.Query(query, workerID, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch timesheet: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []types.TimesheetLine{}
+	for rows.Next() {
+		var l types.TimesheetLine
+		if err := rows.Scan(&l.Period, &l.WorkOrderID, &l.MOID, &l.StepName, &l.ProductName, &l.Entries, &l.Minutes); err != nil {
+			return nil, fmt.Errorf("could not scan timesheet line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return lines, nil
+}
+
+// AssignWorkOrder hands a work order that is not running to a worker, or
+// releases it when workerID is nil. The worker must hold every
+// certification the work requires.
+func (p *Postgres) AssignWorkOrder(id int, workerID *int) (*types.WorkOrder, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow("SELECT status FROM work_orders WHERE id = $1 FOR UPDATE", id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	if status != manufacturing.WOPending && status != manufacturing.WOPaused {
+		return nil, fmt.Errorf("%w: cannot reassign a work order that is %s", manufacturing.ErrGuardFailed, status)
+	}
+
+	if workerID != nil {
+		if _, err := p.GetUserByID(*workerID); err != nil {
+			return nil, err
+		}
+		if err := checkSkillsTx(tx, id, *workerID); err != nil {
+			return nil, err
+		}
+	}
+
+	wo, err := scanWorkOrder(tx.QueryRow(`
+		UPDATE work_orders
+		SET assigned_worker_id = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+woColumns, workerID, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not assign work order: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &wo, nil
+}
+
+//-----------------work orders---Radiator-------------------------//
+
+//-----------------inventory-----Radiator-------------------------//
+
+// GetStockBalance returns the on-hand quantity of a product from its movements.
+func (p *Postgres) GetStockBalance(productID int) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN movement_type = 'IN' THEN quantity ELSE -quantity END), 0)
+		FROM inventory
+		WHERE product_id = $1
+	`
+	var balance float64
+	if err := p.db.QueryRow(query, productID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("could not fetch stock balance: %w", err)
+	}
+	return balance, nil
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// freeStock is on-hand stock minus what other orders have reserved.
+func freeStock(q queryRower, productID, excludeMOID int) (float64, error) {
+	query := `
+		SELECT
+			COALESCE((SELECT SUM(CASE WHEN movement_type = 'IN' THEN quantity ELSE -quantity END)
+			          FROM inventory WHERE product_id = $1), 0)
+			-
+			COALESCE((SELECT SUM(quantity) FROM material_reservations
+			          WHERE component_id = $1 AND status = 'active' AND mo_id <> $2), 0)
+	`
+	var free float64
+	if err := q.QueryRow(query, productID, excludeMOID).Scan(&free); err != nil {
+		return 0, fmt.Errorf("could not fetch free stock: %w", err)
+	}
+	return free, nil
+}
+
+// GetFreeStock returns the stock of a product not reserved by any order other
+// than excludeMOID (0 counts every reservation).
+func (p *Postgres) GetFreeStock(productID, excludeMOID int) (float64, error) {
+	return freeStock(p.db, productID, excludeMOID)
+}
+
+// reservationLock serialises reservations so two orders cannot both be
+// promised the same units.
+const reservationLock = 3401
+
+// reserveMaterialsTx earmarks free stock, substitutes included, for the
+// requirements of an order. Earlier reservations of the order are replaced;
+// shortages stay unreserved.
+func (p *Postgres) reserveMaterialsTx(tx *sql.Tx, mo *types.ManufacturingOrder) ([]planning.ComponentAvailability, error) {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", reservationLock); err != nil {
+		return nil, fmt.Errorf("could not lock reservations: %w", err)
+	}
+	if err := p.releaseReservationsTx(tx, mo.ID); err != nil {
+		return nil, err
+	}
+
+	stockOf := func(id int) (float64, error) { return freeStock(tx, id, mo.ID) }
+	lines, err := planning.Availability(bomSource{tx}, stockOf, mo.ProductID, float64(mo.Quantity))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		for _, a := range line.Allocations {
+			_, err := tx.Exec(`
+				INSERT INTO material_reservations (mo_id, bom_id, component_id, quantity, substitute, alternate_id)
+				VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0))`,
+				mo.ID, line.BoMID, a.ComponentID, a.Quantity, a.Substitute, a.AlternateID)
+			if err != nil {
+				return nil, fmt.Errorf("could not reserve component %d: %w", a.ComponentID, err)
+			}
+		}
+	}
+	return lines, nil
+}
+
+func (p *Postgres) releaseReservationsTx(tx *sql.Tx, moID int) error {
+	_, err := tx.Exec(`
+		UPDATE material_reservations
+		SET status = 'released', updated_at = NOW()
+		WHERE mo_id = $1 AND status = 'active'`, moID)
+	if err != nil {
+		return fmt.Errorf("could not release reservations: %w", err)
+	}
+	return nil
+}
+
+// ReserveMaterials re-runs the reservation of a confirmed or running order,
+// e.g. after stock arrived for a shortage.
+func (p *Postgres) ReserveMaterials(moID int) ([]types.MaterialReservation, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	mo, err := scanMO(tx.QueryRow(`SELECT `+moColumns+` FROM manufacturing_orders WHERE id = $1 FOR UPDATE`, moID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "manufacturing order with id %d not found", moID)
+		}
+		return nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+	}
+	if mo.Status != manufacturing.StatusConfirmed && mo.Status != manufacturing.StatusInProgress {
+		return nil, fmt.Errorf("%w: materials are reserved for confirmed or running orders, this one is %s",
+			manufacturing.ErrGuardFailed, mo.Status)
+	}
+
+	if _, err := p.reserveMaterialsTx(tx, &mo); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return p.GetMOReservations(moID)
+}
+
+// GetMOReservations returns the active reservations of an order.
+func (p *Postgres) GetMOReservations(moID int) ([]types.MaterialReservation, error) {
+	query := `
+		SELECT id, mo_id, bom_id, component_id, quantity, substitute, alternate_id, status, created_at, updated_at
+		FROM material_reservations
+		WHERE mo_id = $1 AND status = 'active'
+		ORDER BY id ASC
+	`
+
+	rows, err := p.db.Query(query, moID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []types.MaterialReservation
+	for rows.Next() {
+		var res types.MaterialReservation
+		if err := rows.Scan(
+			&res.ID,
+			&res.MOID,
+			&res.BoMID,
+			&res.ComponentID,
+			&res.Quantity,
+			&res.Substitute,
+			&res.AlternateID,
+			&res.Status,
+			&res.CreatedAt,
+			&res.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan reservation: %w", err)
+		}
+		reservations = append(reservations, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// Reference types of inventory movements.
+const (
+	refMO    = "mo"
+	refScrap = "scrap"
+)
+
+// inventoryLock namespaces the per-product advisory locks that keep
+// current_balance consistent while movements are posted.
+const inventoryLock = 3402
+
+const inventoryColumns = `id, product_id, movement_type, quantity, date, reference_type, reference_id, current_balance, lot_number, serial_number, created_at, updated_at`
+
+func scanInventory(row rowScanner) (types.Inventory, error) {
+	var m types.Inventory
+	err := row.Scan(
+		&m.ID,
+		&m.ProductID,
+		&m.MovementType,
+		&m.Quantity,
+		&m.Date,
+		&m.ReferenceType,
+		&m.ReferenceID,
+		&m.CurrentBalance,
+		&m.LotNumber,
+		&m.SerialNumber,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	return m, err
+}
+
+// postMovementTx records a stock movement together with the balance it leaves.
+func postMovementTx(tx *sql.Tx, m types.Inventory) (*types.Inventory, error) {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, $2)", inventoryLock, m.ProductID); err != nil {
+		return nil, fmt.Errorf("could not lock stock of product %d: %w", m.ProductID, err)
+	}
+	if err := checkTrackingTx(tx, m); err != nil {
+		return nil, err
+	}
+
+	var balance float64
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN movement_type = 'IN' THEN quantity ELSE -quantity END), 0)
+		FROM inventory
+		WHERE product_id = $1`, m.ProductID).Scan(&balance)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch stock balance: %w", err)
+	}
+	if m.MovementType == "OUT" {
+		balance -= m.Quantity
+	} else {
+		balance += m.Quantity
+	}
+
+	posted, err := scanInventory(tx.QueryRow(`
+		INSERT INTO inventory (product_id, movement_type, quantity, reference_type, reference_id, current_balance, lot_number, serial_number)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+inventoryColumns,
+		m.ProductID, m.MovementType, m.Quantity, m.ReferenceType, m.ReferenceID, balance, m.LotNumber, m.SerialNumber))
+	if err != nil {
+		return nil, fmt.Errorf("could not post %s movement for product %d: %w", m.MovementType, m.ProductID, err)
+	}
+	return &posted, nil
+}
+
+// lotBalanceTx returns the stock held under one lot or serial number.
+func lotBalanceTx(tx *sql.Tx, productID int, column, number string) (float64, error) {
+	var balance float64
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN movement_type = 'IN' THEN quantity ELSE -quantity END), 0)
+		FROM inventory
+		WHERE product_id = $1 AND `+column+` = $2`, productID, number).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("could not fetch %s balance: %w", column, err)
+	}
+	return balance, nil
+}
+
+// checkTrackingTx enforces the product's tracking on a movement: lot-tracked
+// stock moves under a lot number, serialised stock one numbered unit at a
+// time, and nothing leaves a lot or serial it is not in.
+func checkTrackingTx(tx *sql.Tx, m types.Inventory) error {
+	var tracking string
+	if err := tx.QueryRow("SELECT tracking FROM products WHERE id = $1", m.ProductID).Scan(&tracking); err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Newf(apperr.NotFound, "product with id %d not found", m.ProductID)
+		}
+		return fmt.Errorf("could not fetch product tracking: %w", err)
+	}
+
+	switch tracking {
+	case trace.TrackingLot:
+		if m.LotNumber == nil || *m.LotNumber == "" || m.SerialNumber != nil {
+			return fmt.Errorf("%w: product %d moves by lot number", trace.ErrTracking, m.ProductID)
+		}
+		if m.MovementType == "OUT" {
+			balance, err := lotBalanceTx(tx, m.ProductID, "lot_number", *m.LotNumber)
+			if err != nil {
+				return err
+			}
+			if balance+1e-9 < m.Quantity {
+				return fmt.Errorf("%w: lot %s of product %d holds %v, %v requested",
+					trace.ErrTracking, *m.LotNumber, m.ProductID, balance, m.Quantity)
+			}
+		}
+
+	case trace.TrackingSerial:
+		if m.SerialNumber == nil || *m.SerialNumber == "" {
+			return fmt.Errorf("%w: product %d moves by serial number", trace.ErrTracking, m.ProductID)
+		}
+		if m.Quantity != 1 {
+			return fmt.Errorf("%w: serial number %s must move one unit at a time", trace.ErrTracking, *m.SerialNumber)
+		}
+		balance, err := lotBalanceTx(tx, m.ProductID, "serial_number", *m.SerialNumber)
+		if err != nil {
+			return err
+		}
+		if m.MovementType == "IN" && balance > 0 {
+			return fmt.Errorf("%w: serial number %s is already in stock", trace.ErrTracking, *m.SerialNumber)
+		}
+		if m.MovementType == "OUT" && balance < 1 {
+			return fmt.Errorf("%w: serial number %s is not in stock", trace.ErrTracking, *m.SerialNumber)
+		}
+
+	default:
+		if m.LotNumber != nil || m.SerialNumber != nil {
+			return fmt.Errorf("%w: product %d is not lot or serial tracked", trace.ErrTracking, m.ProductID)
+		}
+	}
+	return nil
+}
+
+// issueTx posts an OUT movement. When the product is tracked and the movement
+// names no lot or serial, stock is taken from the oldest lots first and one
+// movement is posted per lot. Serial-tracked products are only issued in whole
+// units.
+func issueTx(tx *sql.Tx, m types.Inventory) ([]types.Inventory, error) {
+	if m.LotNumber != nil || m.SerialNumber != nil {
+		posted, err := postMovementTx(tx, m)
+		if err != nil {
+			return nil, err
+		}
+		return []types.Inventory{*posted}, nil
+	}
+
+	var tracking string
+	if err := tx.QueryRow("SELECT tracking FROM products WHERE id = $1", m.ProductID).Scan(&tracking); err != nil {
+		return nil, fmt.Errorf("could not fetch product tracking: %w", err)
+	}
+	if tracking == trace.TrackingNone {
+		posted, err := postMovementTx(tx, m)
+		if err != nil {
+			return nil, err
+		}
+		return []types.Inventory{*posted}, nil
+	}
+	if tracking == trace.TrackingSerial && m.Quantity != math.Trunc(m.Quantity) {
+		return nil, fmt.Errorf("%w: product %d is serial-tracked and cannot be issued in a quantity of %v",
+			trace.ErrTracking, m.ProductID, m.Quantity)
+	}
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, $2)", inventoryLock, m.ProductID); err != nil {
+		return nil, fmt.Errorf("could not lock stock of product %d: %w", m.ProductID, err)
+	}
+	lots, err := lotBalances(tx, m.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	var posted []types.Inventory
+	left := m.Quantity
+	for _, lot := range lots {
+		if left <= 1e-9 {
+			break
+		}
+		out := m
+		out.LotNumber = lot.LotNumber
+		out.SerialNumber = lot.SerialNumber
+		out.Quantity = min(lot.Quantity, left)
+		if tracking == trace.TrackingSerial {
+			out.LotNumber = nil
+			out.Quantity = 1
+		}
+		mv, err := postMovementTx(tx, out)
+		if err != nil {
+			return nil, err
+		}
+		posted = append(posted, *mv)
+		left -= out.Quantity
+	}
+	if left > 1e-9 {
+		return nil, fmt.Errorf("%w: %v of product %d is not in stock under any %s number",
+			trace.ErrTracking, left, m.ProductID, tracking)
+	}
+	return posted, nil
+}
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// bomSource reads BoMs and products through q, so explosions made inside a
+// transaction see the same snapshot as the stock they are checked against.
+type bomSource struct {
+	q interface {
+		queryer
+		queryRower
+	}
+}
+
+// lotBalances returns the lots and serials of a product that still hold
+// stock, oldest first.
+func lotBalances(q queryer, productID int) ([]types.LotBalance, error) {
+	rows, err := q.Query(`
+		SELECT lot_number, serial_number,
+		       SUM(CASE WHEN movement_type = 'IN' THEN quantity ELSE -quantity END) AS balance
+		FROM inventory
+		WHERE product_id = $1 AND (lot_number IS NOT NULL OR serial_number IS NOT NULL)
+		GROUP BY lot_number, serial_number
+		HAVING SUM(CASE WHEN movement_type = 'IN' THEN quantity ELSE -quantity END) > 0
+		ORDER BY MIN(id) ASC`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch lot balances: %w", err)
+	}
+	defer rows.Close()
+
+	var lots []types.LotBalance
+	for rows.Next() {
+		lot := types.LotBalance{ProductID: productID}
+		if err := rows.Scan(&lot.LotNumber, &lot.SerialNumber, &lot.Quantity); err != nil {
+			return nil, fmt.Errorf("could not scan lot balance: %w", err)
+		}
+		lots = append(lots, lot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return lots, nil
+}
+
+func moMovement(mo *types.ManufacturingOrder, productID int, movementType string, quantity float64) types.Inventory {
+	return movement(refMO, mo.ID, productID, movementType, quantity)
+}
+
+func movement(refType string, refID, productID int, movementType string, quantity float64) types.Inventory {
+	return types.Inventory{
+		ProductID:     productID,
+		MovementType:  movementType,
+		Quantity:      quantity,
+		ReferenceType: &refType,
+		ReferenceID:   &refID,
+	}
+}
+
+// produceTx posts the stock effect of producing quantity units of an order:
+// the finished goods come in under the lot or serials of out and, for
+// backflushed products, the components go out.
+func (p *Postgres) produceTx(tx *sql.Tx, mo *types.ManufacturingOrder, quantity float64, out types.ProductionOutput) error {
+	if quantity <= 0 {
+		return nil
+	}
+
+	var mode, tracking string
+	err := tx.QueryRow("SELECT consumption_mode, tracking FROM products WHERE id = $1", mo.ProductID).Scan(&mode, &tracking)
+	if err != nil {
+		return fmt.Errorf("could not fetch product settings: %w", err)
+	}
+	if mode == manufacturing.ConsumptionBackflush {
+		if err := p.backflushTx(tx, mo, quantity, refMO, mo.ID); err != nil {
+			return err
+		}
+	}
+
+	switch tracking {
+	case trace.TrackingSerial:
+		if float64(len(out.SerialNumbers)) != quantity {
+			return fmt.Errorf("%w: %v units produced but %d serial numbers given",
+				trace.ErrTracking, quantity, len(out.SerialNumbers))
+		}
+		for _, serial := range out.SerialNumbers {
+			m := moMovement(mo, mo.ProductID, "IN", 1)
+			m.SerialNumber = &serial
+			if _, err := postMovementTx(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case trace.TrackingLot:
+		lot := out.LotNumber
+		if lot == "" {
+			lot = fmt.Sprintf("MO-%d", mo.ID)
+		}
+		m := moMovement(mo, mo.ProductID, "IN", quantity)
+		m.LotNumber = &lot
+		_, err := postMovementTx(tx, m)
+		return err
+	}
+
+	_, err = postMovementTx(tx, moMovement(mo, mo.ProductID, "IN", quantity))
+	return err
+}
+
+type activeReservation struct {
+	id          int
+	componentID int
+	quantity    float64
+	ratio       float64
+}
+
+// backflushTx consumes the components of quantity units, posting the
+// movements against refType/refID. Each BoM line draws on what the order
+// reserved for it first, substitutes included, and takes any remainder from
+// the primary component.
+func (p *Postgres) backflushTx(tx *sql.Tx, mo *types.ManufacturingOrder, quantity float64, refType string, refID int) error {
+	tree, err := bom.Explode(bomSource{tx}, mo.ProductID, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range tree.Requirements() {
+		need := req.Quantity * quantity
+
+		rows, err := tx.Query(`
+			SELECT r.id, r.component_id, r.quantity, COALESCE(a.conversion_ratio, 1)
+			FROM material_reservations r
+			LEFT JOIN bom_alternates a ON a.id = r.alternate_id
+			WHERE r.mo_id = $1 AND r.bom_id = $2 AND r.status = 'active'
+			ORDER BY r.substitute ASC, r.id ASC`, mo.ID, req.BoMID)
+		if err != nil {
+			return fmt.Errorf("could not fetch reservations: %w", err)
+		}
+		var reserved []activeReservation
+		for rows.Next() {
+			var r activeReservation
+			if err := rows.Scan(&r.id, &r.componentID, &r.quantity, &r.ratio); err != nil {
+				rows.Close()
+				return fmt.Errorf("could not scan reservation: %w", err)
+			}
+			reserved = append(reserved, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows error: %w", err)
+		}
+
+		for _, r := range reserved {
+			if need <= 1e-9 {
+				break
+			}
+			covered := min(r.quantity/r.ratio, need)
+			units := covered * r.ratio
+			if _, err := issueTx(tx, movement(refType, refID, r.componentID, "OUT", units)); err != nil {
+				return err
+			}
+			if err := consumeReservationTx(tx, r.id, units); err != nil {
+				return err
+			}
+			need -= covered
+		}
+
+		if need > 1e-9 {
+			if _, err := issueTx(tx, movement(refType, refID, req.ComponentID, "OUT", need)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// consumeReservationTx takes units off a reservation, closing it once used up.
+func consumeReservationTx(tx *sql.Tx, reservationID int, units float64) error {
+	_, err := tx.Exec(`
+		UPDATE material_reservations
+		SET quantity = GREATEST(quantity - $1, 0),
+		    status = CASE WHEN quantity - $1 <= 0.0001 THEN 'consumed' ELSE status END,
+		    updated_at = NOW()
+		WHERE id = $2`, units, reservationID)
+	if err != nil {
+		return fmt.Errorf("could not update reservation: %w", err)
+	}
+	return nil
+}
+
+// ConsumeMaterial posts a manual component issue against a running order of
+// a product that is not backflushed. Tracked components are taken from the
+// given lot or serial, or from the oldest lots when none is given.
+func (p *Postgres) ConsumeMaterial(moID, componentID int, quantity float64, lotNumber, serialNumber string) ([]types.Inventory, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	mo, err := scanMO(tx.QueryRow(`SELECT `+moColumns+` FROM manufacturing_orders WHERE id = $1 FOR UPDATE`, moID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "manufacturing order with id %d not found", moID)
+		}
+		return nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+	}
+	if mo.Status != manufacturing.StatusInProgress {
+		return nil, fmt.Errorf("%w: materials are consumed by running orders, this one is %s",
+			manufacturing.ErrGuardFailed, mo.Status)
+	}
+
+	var mode string
+	if err := tx.QueryRow("SELECT consumption_mode FROM products WHERE id = $1", mo.ProductID).Scan(&mode); err != nil {
+		return nil, fmt.Errorf("could not fetch consumption mode: %w", err)
+	}
+	if mode != manufacturing.ConsumptionManual {
+		return nil, fmt.Errorf("%w: product %d is backflushed at completion", manufacturing.ErrGuardFailed, mo.ProductID)
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", componentID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error checking component existence: %w", err)
+	}
+	if !exists {
+		return nil, apperr.Newf(apperr.NotFound, "component product with id %d not found", componentID)
+	}
+	onBoM, err := onBoMTx(tx, mo.ProductID, componentID)
+	if err != nil {
+		return nil, err
+	}
+	if !onBoM {
+		return nil, fmt.Errorf("%w: product %d is not a component of the BoM of order %d",
+			manufacturing.ErrGuardFailed, componentID, moID)
+	}
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, $2)", inventoryLock, componentID); err != nil {
+		return nil, fmt.Errorf("could not lock stock of product %d: %w", componentID, err)
+	}
+	var onHand float64
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN movement_type = 'IN' THEN quantity ELSE -quantity END), 0)
+		FROM inventory
+		WHERE product_id = $1`, componentID).Scan(&onHand)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch stock balance: %w", err)
+	}
+	if onHand < quantity {
+		return nil, fmt.Errorf("%w: only %v of product %d is in stock", manufacturing.ErrGuardFailed, onHand, componentID)
+	}
+
+	m := moMovement(&mo, componentID, "OUT", quantity)
+	if lotNumber != "" {
+		m.LotNumber = &lotNumber
+	}
+	if serialNumber != "" {
+		m.SerialNumber = &serialNumber
+	}
+	movements, err := issueTx(tx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	// what was issued no longer needs to be held for the order
+	rows, err := tx.Query(`
+		SELECT id, quantity FROM material_reservations
+		WHERE mo_id = $1 AND component_id = $2 AND status = 'active'
+		ORDER BY id ASC`, moID, componentID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch reservations: %w", err)
+	}
+	var reserved []activeReservation
+	for rows.Next() {
+		var r activeReservation
+		if err := rows.Scan(&r.id, &r.quantity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan reservation: %w", err)
+		}
+		reserved = append(reserved, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	rows.Close()
+
+	left := quantity
+	for _, r := range reserved {
+		if left <= 1e-9 {
+			break
+		}
+		units := min(r.quantity, left)
+		if err := consumeReservationTx(tx, r.id, units); err != nil {
+			return nil, err
+		}
+		left -= units
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return movements, nil
+}
+
+// onBoMTx reports whether componentID is required to build productID, either
+// as a BoM line (phantoms blown through) or as an alternate of one.
+func onBoMTx(tx *sql.Tx, productID, componentID int) (bool, error) {
+	src := bomSource{tx}
+	tree, err := bom.Explode(src, productID, 0)
+	if err != nil {
+		return false, err
+	}
+	for _, req := range tree.Requirements() {
+		if req.ComponentID == componentID {
+			return true, nil
+		}
+		alts, err := src.GetBoMAlternates(req.BoMID)
+		if err != nil {
+			return false, err
+		}
+		for _, a := range alts {
+			if a.ComponentID == componentID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// GetMOMovements returns the stock movements posted by an order.
+func (p *Postgres) GetMOMovements(moID int) ([]types.Inventory, error) {
+	query := `
+		SELECT ` + inventoryColumns + `
+		FROM inventory
+		WHERE reference_type = $1 AND reference_id = $2
+		ORDER BY id ASC
+	`
+
+	rows, err := p.db.Query(query, refMO, moID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []types.Inventory
+	for rows.Next() {
+		m, err := scanInventory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan movement: %w", err)
+		}
+		movements = append(movements, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return movements, nil
+}
+
+// PostMovement records a stock movement that is not tied to an order, such
+// as a goods receipt or an adjustment.
+func (p *Postgres) PostMovement(m types.Inventory) ([]types.Inventory, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var posted []types.Inventory
+	if m.MovementType == "OUT" {
+		posted, err = issueTx(tx, m)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		mv, err := postMovementTx(tx, m)
+		if err != nil {
+			return nil, err
+		}
+		posted = []types.Inventory{*mv}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return posted, nil
+}
+
+// GetLotBalances returns the lots and serials of a product in stock, oldest first.
+func (p *Postgres) GetLotBalances(productID int) ([]types.LotBalance, error) {
+	return lotBalances(p.db, productID)
+}
+
+// GetTrackedMovements returns the production movements of one lot or serial
+// number of a product, i.e. those posted by manufacturing orders.
+func (p *Postgres) GetTrackedMovements(productID int, lotNumber, serialNumber, movementType string) ([]types.Inventory, error) {
+	query := `
+		SELECT ` + inventoryColumns + `
+		FROM inventory
+		WHERE product_id = $1
+		  AND movement_type = $2
+		  AND reference_type = $3
+		  AND ($4::text = '' OR lot_number = $4::text)
+		  AND ($5::text = '' OR serial_number = $5::text)
+		ORDER BY id ASC
+	`
+
+	rows, err := p.db.Query(query, productID, movementType, refMO, lotNumber, serialNumber)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []types.Inventory
+	for rows.Next() {
+		m, err := scanInventory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan movement: %w", err)
+		}
+		movements = append(movements, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return movements, nil
+}
+
+//-----------------inventory-----Radiator-------------------------//
+
+//-----------------scrap---------Radiator-------------------------//
+
+const scrapRecordColumns = `id, work_order_id, mo_id, product_id, work_center_id, reason_id, quantity, rework,
+	rework_work_order_id, reported_by, notes, created_at`
+
+func scanScrapRecord(row rowScanner) (types.ScrapRecord, error) {
+	var r types.ScrapRecord
+	err := row.Scan(
+		&r.ID,
+		&r.WorkOrderID,
+		&r.MOID,
+		&r.ProductID,
+		&r.WorkCenterID,
+		&r.ReasonID,
+		&r.Quantity,
+		&r.Rework,
+		&r.ReworkWorkOrderID,
+		&r.ReportedBy,
+		&r.Notes,
+		&r.CreatedAt,
+	)
+	return r, err
+}
+
+func (p *Postgres) CreateScrapReason(reason types.ScrapReason) (*types.ScrapReason, error) {
+	query := `
+		INSERT INTO scrap_reasons (code, description, active)
+		VALUES ($1, $2, $3)
+		RETURNING id, code, description, active, created_at, updated_at
+	`
+
+	var created types.ScrapReason
+	err := p.db.QueryRow(query, reason.Code, reason.Description, reason.Active).Scan(
+		&created.ID, &created.Code, &created.Description, &created.Active, &created.CreatedAt, &created.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not create scrap reason: %w", err)
+	}
+	return &created, nil
+}
+
+func (p *Postgres) UpdateScrapReason(reason types.ScrapReason) (*types.ScrapReason, error) {
+	query := `
+		UPDATE scrap_reasons
+		SET code = $1, description = $2, active = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING id, code, description, active, created_at, updated_at
+	`
+
+	var updated types.ScrapReason
+	err := p.db.QueryRow(query, reason.Code, reason.Description, reason.Active, reason.ID).Scan(
+		&updated.ID, &updated.Code, &updated.Description, &updated.Active, &updated.CreatedAt, &updated.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "scrap reason with id %d not found", reason.ID)
+		}
+		return nil, fmt.Errorf("could not update scrap reason: %w", err)
+	}
+	return &updated, nil
+}
+
+func (p *Postgres) GetScrapReasons() ([]types.ScrapReason, error) {
+	query := `
+		SELECT id, code, description, active, created_at, updated_at
+		FROM scrap_reasons
+		ORDER BY code ASC
+	`
+
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch scrap reasons: %w", err)
+	}
+	defer rows.Close()
+
+	var reasons []types.ScrapReason
+	for rows.Next() {
+		var r types.ScrapReason
+		if err := rows.Scan(&r.ID, &r.Code, &r.Description, &r.Active, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan scrap reason: %w", err)
+		}
+		reasons = append(reasons, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return reasons, nil
+}
+
+// ReportScrap records units rejected at a work order. Scrapped units are
+// lost: they are written off with a scrap movement, their components are
+// backflushed as scrap unless the product is consumed manually, and the
+// order's expected good output drops. Reworked units stay in the order and get a
+// rework work order at the same work center, which the original's
+// successors then wait for.
+func (p *Postgres) ReportScrap(rec types.ScrapRecord) (*types.ScrapRecord, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	wo, err := scanWorkOrder(tx.QueryRow(`SELECT `+woColumns+` FROM work_orders WHERE id = $1 FOR UPDATE`, rec.WorkOrderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work order with id %d not found", rec.WorkOrderID)
+		}
+		return nil, fmt.Errorf("could not fetch work order: %w", err)
+	}
+	if wo.Status == manufacturing.WOPending {
+		return nil, fmt.Errorf("%w: work order %d has not started", manufacturing.ErrGuardFailed, wo.ID)
+	}
+
+	mo, err := scanMO(tx.QueryRow(`SELECT `+moColumns+` FROM manufacturing_orders WHERE id = $1 FOR UPDATE`, wo.MOID))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch manufacturing order: %w", err)
+	}
+	if mo.Status != manufacturing.StatusInProgress {
+		return nil, fmt.Errorf("%w: scrap is reported on running orders, this one is %s",
+			manufacturing.ErrGuardFailed, mo.Status)
+	}
+
+	var active bool
+	if err := tx.QueryRow("SELECT active FROM scrap_reasons WHERE id = $1", rec.ReasonID).Scan(&active); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "scrap reason with id %d not found", rec.ReasonID)
+		}
+		return nil, fmt.Errorf("could not fetch scrap reason: %w", err)
+	}
+	if !active {
+		return nil, fmt.Errorf("%w: scrap reason %d is no longer in use", manufacturing.ErrGuardFailed, rec.ReasonID)
+	}
+
+	if remaining := mo.Quantity - mo.ProducedQuantity - mo.ScrappedQuantity; rec.Quantity > remaining {
+		return nil, fmt.Errorf("%w: only %d units of the order are still in work", manufacturing.ErrGuardFailed, remaining)
+	}
+
+	if rec.Rework {
+		var perUnit float64
+		if wo.OperationID != nil {
+			err := tx.QueryRow("SELECT run_minutes_per_unit FROM routing_operations WHERE id = $1", *wo.OperationID).Scan(&perUnit)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, fmt.Errorf("could not fetch routing operation: %w", err)
+			}
+		}
+		stepName := "Rework: " + wo.StepName
+		if len(stepName) > 100 {
+			stepName = stepName[:100]
+		}
+
+		var reworkID int
+		err := tx.QueryRow(`
+			INSERT INTO work_orders (mo_id, step_name, status, work_center_id, sequence, operation_id, planned_minutes, rework_of_id)
+			VALUES ($1, $2, 'pending', $3, $4, $5, $6, $7)
+			RETURNING id`,
+			wo.MOID, stepName, wo.WorkCenterID, wo.Sequence, wo.OperationID, perUnit*float64(rec.Quantity), wo.ID).Scan(&reworkID)
+		if err != nil {
+			return nil, fmt.Errorf("could not create rework work order: %w", err)
+		}
+
+		// the rework follows the rejecting step and holds up whatever has
+		// not started after it
+		_, err = tx.Exec(`
+			INSERT INTO work_order_dependencies (work_order_id, predecessor_id)
+			SELECT $1, $2
+			UNION ALL
+			SELECT d.work_order_id, $1
+			FROM work_order_dependencies d
+			JOIN work_orders s ON s.id = d.work_order_id
+			WHERE d.predecessor_id = $2 AND s.status = 'pending'
+			ON CONFLICT (work_order_id, predecessor_id) DO NOTHING`, reworkID, wo.ID)
+		if err != nil {
+			return nil, fmt.Errorf("could not link rework work order: %w", err)
+		}
+		if err := p.rankWorkOrdersTx(tx, &mo); err != nil {
+			return nil, err
+		}
+		rec.ReworkWorkOrderID = &reworkID
+	}
+
+	created, err := scanScrapRecord(tx.QueryRow(`
+		INSERT INTO scrap_records (work_order_id, mo_id, product_id, work_center_id, reason_id, quantity, rework,
+		                           rework_work_order_id, reported_by, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING `+scrapRecordColumns,
+		wo.ID, mo.ID, mo.ProductID, wo.WorkCenterID, rec.ReasonID, rec.Quantity, rec.Rework,
+		rec.ReworkWorkOrderID, rec.ReportedBy, rec.Notes))
+	if err != nil {
+		return nil, fmt.Errorf("could not record scrap: %w", err)
+	}
+
+	if !rec.Rework {
+		var mode string
+		if err := tx.QueryRow("SELECT consumption_mode FROM products WHERE id = $1", mo.ProductID).Scan(&mode); err != nil {
+			return nil, fmt.Errorf("could not fetch consumption mode: %w", err)
+		}
+		// manually consumed components were issued to the order already
+		if mode == manufacturing.ConsumptionBackflush {
+			if err := p.backflushTx(tx, &mo, float64(rec.Quantity), refScrap, created.ID); err != nil {
+				return nil, err
+			}
+		}
+		if err := scrapOutputTx(tx, &mo, rec.Quantity, created.ID); err != nil {
+			return nil, err
+		}
+		_, err := tx.Exec(`
+			UPDATE manufacturing_orders
+			SET scrapped_quantity = scrapped_quantity + $1, updated_at = NOW()
+			WHERE id = $2`, rec.Quantity, mo.ID)
+		if err != nil {
+			return nil, fmt.Errorf("could not update scrapped quantity: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &created, nil
+}
+
+// scrapOutputTx books the scrapped units of an order's product in the stock
+// ledger. They were never received, so each is posted IN and straight back
+// OUT under reference "scrap": the loss shows as an OUT movement while the
+// balance stays as it was. Tracked units carry a SCRAP-<record> lot or serial.
+func scrapOutputTx(tx *sql.Tx, mo *types.ManufacturingOrder, quantity, recordID int) error {
+	var tracking string
+	if err := tx.QueryRow("SELECT tracking FROM products WHERE id = $1", mo.ProductID).Scan(&tracking); err != nil {
+		return fmt.Errorf("could not fetch product tracking: %w", err)
+	}
+
+	writeOff := func(m types.Inventory) error {
+		for _, kind := range []string{"IN", "OUT"} {
+			m.MovementType = kind
+			if _, err := postMovementTx(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch tracking {
+	case trace.TrackingSerial:
+		for i := 1; i <= quantity; i++ {
+			m := movement(refScrap, recordID, mo.ProductID, "", 1)
+			serial := fmt.Sprintf("SCRAP-%d-%d", recordID, i)
+			m.SerialNumber = &serial
+			if err := writeOff(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	case trace.TrackingLot:
+		m := movement(refScrap, recordID, mo.ProductID, "", float64(quantity))
+		lot := fmt.Sprintf("SCRAP-%d", recordID)
+		m.LotNumber = &lot
+		return writeOff(m)
+	}
+	return writeOff(movement(refScrap, recordID, mo.ProductID, "", float64(quantity)))
+}
+
+func (p *Postgres) GetScrapRecords(workOrderID int) ([]types.ScrapRecord, error) {
+	query := `SELECT ` + scrapRecordColumns + ` FROM scrap_records WHERE work_order_id = $1 ORDER BY id ASC`
+
+	rows, err := p.db.Query(query, workOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch scrap records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []types.ScrapRecord
+	for rows.Next() {
+		r, err := scanScrapRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan scrap record: %w", err)
+		}
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return records, nil
+}
+
+// scrapGroups maps a report grouping to the key column and its display name.
+var scrapGroups = map[string]struct{ key, name, join string }{
+	"product":     {key: "s.product_id", name: "g.name", join: "LEFT JOIN products g ON g.id = s.product_id"},
+	"work_center": {key: "s.work_center_id", name: "g.name", join: "LEFT JOIN work_centers g ON g.id = s.work_center_id"},
+	"reason":      {key: "s.reason_id", name: "g.code", join: "LEFT JOIN scrap_reasons g ON g.id = s.reason_id"},
+}
+
+// GetScrapReport totals scrapped and reworked units by product, work center
+// or reason, optionally limited to records created in [from, to].
+func (p *Postgres) GetScrapReport(groupBy string, from, to *time.Time) ([]types.ScrapReportLine, error) {
+	g, ok := scrapGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown scrap report grouping %q", groupBy)
+	}
+
+	query := `
+		SELECT ` + g.key + `, COALESCE(` + g.name + `, 'unassigned'), COUNT(*),
+		       COALESCE(SUM(s.quantity) FILTER (WHERE NOT s.rework), 0),
+		       COALESCE(SUM(s.quantity) FILTER (WHERE s.rework), 0)
+		FROM scrap_records s
+		` + g.join + `
+		WHERE ($1::timestamp IS NULL OR s.created_at >= $1)
+		  AND ($2::timestamp IS NULL OR s.created_at <= $2)
+		GROUP BY ` + g.key + `, ` + g.name + `
+		ORDER BY 4 DESC, 5 DESC
+	`
+
+	rows, err := p.db.Query(query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch scrap report: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []types.ScrapReportLine{}
+	for rows.Next() {
+		var l types.ScrapReportLine
+		if err := rows.Scan(&l.ID, &l.Name, &l.Records, &l.Scrapped, &l.Reworked); err != nil {
+			return nil, fmt.Errorf("could not scan scrap report line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return lines, nil
+}
+
+// GetWorkCenterScrap returns the scrap and rework found at a work center
+// on the work orders completed there in [from, to), plus what was reported
+// in [from, to) against work orders that have not been completed.
+func (p *Postgres) GetWorkCenterScrap(workCenterID int, from, to time.Time) ([]types.ScrapRecord, error) {
+	query := `SELECT ` + scrapRecordColumns + ` FROM scrap_records s
+		WHERE s.work_center_id = $1
+		  AND EXISTS (
+		      SELECT 1 FROM work_orders w
+		      WHERE w.id = s.work_order_id
+		        AND CASE WHEN w.status = 'completed'
+		                 THEN w.end_time >= $2 AND w.end_time < $3
+		                 ELSE s.created_at >= $2 AND s.created_at < $3 END
+		  )
+		ORDER BY s.created_at ASC`
+
+	rows, err := p.db.Query(query, workCenterID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch scrap records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []types.ScrapRecord{}
+	for rows.Next() {
+		r, err := scanScrapRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan scrap record: %w", err)
+		}
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return records, nil
+}
+
+//-----------------scrap---------Radiator-------------------------//
+
+//-----------------skills--------Radiator-------------------------//
+
+func (p *Postgres) CreateSkill(skill types.Skill) (*types.Skill, error) {
+	query := `
+		INSERT INTO skills (code, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, code, name, description, created_at, updated_at
+	`
+
+	var created types.Skill
+	err := p.db.QueryRow(query, skill.Code, skill.Name, skill.Description).Scan(
+		&created.ID, &created.Code, &created.Name, &created.Description, &created.CreatedAt, &created.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not create skill: %w", err)
+	}
+	return &created, nil
+}
+
+func (p *Postgres) GetSkills() ([]types.Skill, error) {
+	return p.querySkills(`SELECT id, code, name, description, created_at, updated_at FROM skills ORDER BY code ASC`)
+}
+
+func (p *Postgres) DeleteSkill(id int) error {
+	result, err := p.db.Exec("DELETE FROM skills WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("could not delete skill: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return apperr.Newf(apperr.NotFound, "skill with id %d not found", id)
+	}
+	return nil
+}
+
+func (p *Postgres) querySkills(query string, args ...any) ([]types.Skill, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch skills: %w", err)
+	}
+	defer rows.Close()
+
+	skills := []types.Skill{}
+	for rows.Next() {
+		var s types.Skill
+		if err := rows.Scan(&s.ID, &s.Code, &s.Name, &s.Description, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan skill: %w", err)
+		}
+		skills = append(skills, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return skills, nil
+}
+
+const userSkillColumns = `u.id, u.user_id, u.skill_id, s.code, s.name, u.certified_at, u.expires_at, u.reference,
+	COALESCE(u.expires_at < CURRENT_DATE, false), u.created_at, u.updated_at`
+
+func scanUserSkill(row rowScanner, extra ...any) (types.UserSkill, error) {
+	var us types.UserSkill
+	dest := []any{
+		&us.ID,
+		&us.UserID,
+		&us.SkillID,
+		&us.SkillCode,
+		&us.SkillName,
+		&us.CertifiedAt,
+		&us.ExpiresAt,
+		&us.Reference,
+		&us.Expired,
+		&us.CreatedAt,
+		&us.UpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	return us, err
+}
+
+// SetUserSkill records or renews a worker's certification for a skill.
+func (p *Postgres) SetUserSkill(us types.UserSkill) (*types.UserSkill, error) {
+	if _, err := p.GetUserByID(us.UserID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH u AS (
+			INSERT INTO user_skills (user_id, skill_id, certified_at, expires_at, reference)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, skill_id) DO UPDATE
+			SET certified_at = EXCLUDED.certified_at,
+			    expires_at = EXCLUDED.expires_at,
+			    reference = EXCLUDED.reference,
+			    updated_at = NOW()
+			RETURNING *
+		)
+		SELECT ` + userSkillColumns + `
+		FROM u
+		JOIN skills s ON s.id = u.skill_id
+	`
+
+	saved, err := scanUserSkill(p.db.QueryRow(query, us.UserID, us.SkillID, us.CertifiedAt, us.ExpiresAt, us.Reference))
+	if err != nil {
+		if violates(err, "user_skills_skill_id_fkey") {
+			return nil, apperr.Newf(apperr.NotFound, "skill with id %d not found", us.SkillID)
+		}
+		return nil, fmt.Errorf("could not save certification: %w", err)
+	}
+	return &saved, nil
+}
+
+func (p *Postgres) GetUserSkills(userID int) ([]types.UserSkill, error) {
+	query := `
+		SELECT ` + userSkillColumns + `
+		FROM user_skills u
+		JOIN skills s ON s.id = u.skill_id
+		WHERE u.user_id = $1
+		ORDER BY s.code ASC
+	`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch certifications: %w", err)
+	}
+	defer rows.Close()
+
+	skills := []types.UserSkill{}
+	for rows.Next() {
+		us, err := scanUserSkill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan certification: %w", err)
+		}
+		skills = append(skills, us)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return skills, nil
+}
+
+func (p *Postgres) DeleteUserSkill(userID, skillID int) error {
+	result, err := p.db.Exec("DELETE FROM user_skills WHERE user_id = $1 AND skill_id = $2", userID, skillID)
+	if err != nil {
+		return fmt.Errorf("could not delete certification: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return apperr.Newf(apperr.NotFound, "certification for skill %d of user %d not found", skillID, userID)
+	}
+	return nil
+}
+
+// requiredSkills names the tables that attach skill requirements to work
+// centers and to routing operations.
+// requiredSkills maps a kind of requirement to its table and to the query
+// checking that its owner exists; an operation must belong to the product.
+var requiredSkills = map[string]struct{ table, key, exists string }{
+	"work center": {table: "work_center_skills", key: "work_center_id",
+		exists: "SELECT EXISTS (SELECT 1 FROM work_centers WHERE id = $1)"},
+	"operation": {table: "operation_skills", key: "operation_id",
+		exists: "SELECT EXISTS (SELECT 1 FROM routing_operations WHERE id = $1 AND product_id = $2)"},
+}
+
+// checkRequirementOwner fails with not found unless the work center or
+// operation exists; scope carries the product of an operation.
+func checkRequirementOwner(q queryRower, kind string, id int, scope ...any) error {
+	var exists bool
+	if err := q.QueryRow(requiredSkills[kind].exists, append([]any{id}, scope...)...).Scan(&exists); err != nil {
+		return fmt.Errorf("could not check %s: %w", kind, err)
+	}
+	if !exists {
+		return apperr.Newf(apperr.NotFound, "%s with id %d not found", kind, id)
+	}
+	return nil
+}
+
+// setRequiredSkills replaces the skills a work center or operation requires.
+func (p *Postgres) setRequiredSkills(kind string, id int, skillIDs []int, scope ...any) ([]types.Skill, error) {
+	t := requiredSkills[kind]
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkRequirementOwner(tx, kind, id, scope...); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM `+t.table+` WHERE `+t.key+` = $1`, id); err != nil {
+		return nil, fmt.Errorf("could not clear required skills: %w", err)
+	}
+	for _, skillID := range skillIDs {
+		_, err := tx.Exec(`INSERT INTO `+t.table+` (`+t.key+`, skill_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, id, skillID)
+		if err != nil {
+			if violates(err, t.table+"_skill_id_fkey") {
+				return nil, apperr.Newf(apperr.NotFound, "skill with id %d not found", skillID)
+			}
+			return nil, fmt.Errorf("could not add required skill: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return p.getRequiredSkills(kind, id, scope...)
+}
+
+func (p *Postgres) getRequiredSkills(kind string, id int, scope ...any) ([]types.Skill, error) {
+	if err := checkRequirementOwner(p.db, kind, id, scope...); err != nil {
+		return nil, err
+	}
+	t := requiredSkills[kind]
+	return p.querySkills(`
+		SELECT s.id, s.code, s.name, s.description, s.created_at, s.updated_at
+		FROM `+t.table+` r
+		JOIN skills s ON s.id = r.skill_id
+		WHERE r.`+t.key+` = $1
+		ORDER BY s.code ASC`, id)
+}
+
+func (p *Postgres) SetWorkCenterSkills(workCenterID int, skillIDs []int) ([]types.Skill, error) {
+	return p.setRequiredSkills("work center", workCenterID, skillIDs)
+}
+
+func (p *Postgres) GetWorkCenterSkills(workCenterID int) ([]types.Skill, error) {
+	return p.getRequiredSkills("work center", workCenterID)
+}
+
+func (p *Postgres) SetOperationSkills(productID, operationID int, skillIDs []int) ([]types.Skill, error) {
+	return p.setRequiredSkills("operation", operationID, skillIDs, productID)
+}
+
+func (p *Postgres) GetOperationSkills(productID, operationID int) ([]types.Skill, error) {
+	return p.getRequiredSkills("operation", operationID, productID)
+}
+
+// missingSkillsSQL selects the codes of skills work order $1 requires,
+// through its work center or its operation, that worker $2 holds no
+// current certification for.
+const missingSkillsSQL = `
+	SELECT s.code
+	FROM work_orders w
+	JOIN skills s ON s.id IN (
+		SELECT skill_id FROM work_center_skills WHERE work_center_id = w.work_center_id
+		UNION
+		SELECT skill_id FROM operation_skills WHERE operation_id = w.operation_id
+	)
+	WHERE w.id = $1
+	  AND NOT EXISTS (
+		SELECT 1 FROM user_skills u
+		WHERE u.user_id = $2 AND u.skill_id = s.id
+		  AND (u.expires_at IS NULL OR u.expires_at >= CURRENT_DATE)
+	  )
+	ORDER BY s.code`
+
+// qualifiedSQL holds when worker $1 may pick up work at the work center of
+// work order w: work without a center is open to everyone, and a center is
+// open to workers qualified for it. A center that requires skills qualifies
+// every worker holding them, so certification alone is enough there.
+const qualifiedSQL = `(w.work_center_id IS NULL
+	OR EXISTS (SELECT 1 FROM worker_qualifications q WHERE q.worker_id = $1 AND q.work_center_id = w.work_center_id)
+	OR EXISTS (SELECT 1 FROM work_center_skills r WHERE r.work_center_id = w.work_center_id))`
+
+// checkQualifiedTx fails with ErrNotCertified when a worker may not take an
+// unassigned work order: they must be qualified for its work center and hold
+// its skills.
+func checkQualifiedTx(tx *sql.Tx, workOrderID, workerID int) error {
+	var qualified bool
+	err := tx.QueryRow(`SELECT `+qualifiedSQL+` FROM work_orders w WHERE w.id = $2`, workerID, workOrderID).Scan(&qualified)
+	if err != nil {
+		return fmt.Errorf("could not check qualification: %w", err)
+	}
+	if !qualified {
+		return fmt.Errorf("%w: worker %d is not qualified for the work center of work order %d",
+			manufacturing.ErrNotCertified, workerID, workOrderID)
+	}
+	return checkSkillsTx(tx, workOrderID, workerID)
+}
+
+// checkSkillsTx fails with ErrNotCertified when a worker may not work on a
+// work order.
+func checkSkillsTx(q queryer, workOrderID, workerID int) error {
+	rows, err := q.Query(missingSkillsSQL, workOrderID, workerID)
+	if err != nil {
+		return fmt.Errorf("could not check certifications: %w", err)
+	}
+	defer rows.Close()
+
+	var missing []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return fmt.Errorf("could not scan skill: %w", err)
+		}
+		missing = append(missing, code)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: worker %d needs a current certification for %s",
+			manufacturing.ErrNotCertified, workerID, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// GetExpiringCertifications lists certifications that lapse within the
+// next days days, soonest first.
+func (p *Postgres) GetExpiringCertifications(days int) ([]types.ExpiringCertification, error) {
+	query := `
+		SELECT ` + userSkillColumns + `, us.name, u.expires_at - CURRENT_DATE
+		FROM user_skills u
+		JOIN skills s ON s.id = u.skill_id
+		JOIN users us ON us.id = u.user_id
+		WHERE u.expires_at BETWEEN CURRENT_DATE AND CURRENT_DATE + $1::int
+		ORDER BY u.expires_at ASC, us.name ASC
+	`
+
+	rows, err := p.db.Query(query, days)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch expiring certifications: %w", err)
+	}
+	defer rows.Close()
+
+	certs := []types.ExpiringCertification{}
+	for rows.Next() {
+		var c types.ExpiringCertification
+		c.UserSkill, err = scanUserSkill(rows, &c.UserName, &c.DaysLeft)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan certification: %w", err)
+		}
+		certs = append(certs, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return certs, nil
+}
+
+//-----------------skills--------Radiator-------------------------//
+
+//-----------------work centers--Radiator-------------------------//
+
+const wcColumns = `id, name, type, COALESCE(capacity, 0), status, labour_rate, overhead_rate, shift_pattern_id,
+	created_at, updated_at`
+
+func scanWorkCenter(row rowScanner) (types.WorkCenter, error) {
+	var wc types.WorkCenter
+	err := row.Scan(
+		&wc.ID,
+		&wc.Name,
+		&wc.Type,
+		&wc.Capacity,
+		&wc.Status,
+		&wc.LabourRate,
+		&wc.OverheadRate,
+		&wc.ShiftPatternID,
+		&wc.CreatedAt,
+		&wc.UpdatedAt,
+	)
+	return wc, err
+}
+
+func (p *Postgres) CreateWorkCenter(wc types.WorkCenter) (*types.WorkCenter, error) {
+	query := `
+		INSERT INTO work_centers (name, type, capacity, status, labour_rate, overhead_rate)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + wcColumns
+
+	created, err := scanWorkCenter(p.db.QueryRow(query,
+		wc.Name, wc.Type, wc.Capacity, wc.Status, wc.LabourRate, wc.OverheadRate))
+	if err != nil {
+		return nil, fmt.Errorf("could not create work center: %w", err)
+	}
+	return &created, nil
+}
+
+func (p *Postgres) GetWorkCenters() ([]types.WorkCenter, error) {
+	rows, err := p.db.Query(`SELECT ` + wcColumns + ` FROM work_centers ORDER BY name ASC, id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch work centers: %w", err)
+	}
+	defer rows.Close()
+
+	centers := []types.WorkCenter{}
+	for rows.Next() {
+		wc, err := scanWorkCenter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan work center: %w", err)
+		}
+		centers = append(centers, wc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return centers, nil
+}
+
+func (p *Postgres) GetWorkCenterByID(id int) (*types.WorkCenter, error) {
+	wc, err := scanWorkCenter(p.db.QueryRow(`SELECT `+wcColumns+` FROM work_centers WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch work center: %w", err)
+	}
+	return &wc, nil
+}
+
+// UpdateWorkCenter changes a work center's details and rates. Its status is
+// changed through SetWorkCenterStatus.
+func (p *Postgres) UpdateWorkCenter(wc types.WorkCenter) (*types.WorkCenter, error) {
+	query := `
+		UPDATE work_centers
+		SET name = $1, type = $2, capacity = $3, labour_rate = $4, overhead_rate = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING ` + wcColumns
+
+	updated, err := scanWorkCenter(p.db.QueryRow(query,
+		wc.Name, wc.Type, wc.Capacity, wc.LabourRate, wc.OverheadRate, wc.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", wc.ID)
+		}
+		return nil, fmt.Errorf("could not update work center: %w", err)
+	}
+	return &updated, nil
+}
+
+// SetWorkCenterStatus changes the status of a work center. Taking it down
+// opens a breakdown so OEE counts the stoppage; bringing it back ends it.
+// SetWorkCenterRates sets the hourly labour and overhead rates the cost
+// rollup charges for operations at a work center.
+func (p *Postgres) SetWorkCenterRates(id int, labourRate, overheadRate float64) (*types.WorkCenter, error) {
+	query := `
+		UPDATE work_centers
+		SET labour_rate = $1, overhead_rate = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING ` + wcColumns
+
+	updated, err := scanWorkCenter(p.db.QueryRow(query, labourRate, overheadRate, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not update work center rates: %w", err)
+	}
+	return &updated, nil
+}
+
+func (p *Postgres) SetWorkCenterStatus(id int, status string, actorID int) (*types.WorkCenter, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := lockWorkCenterTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanWorkCenter(tx.QueryRow(`
+		UPDATE work_centers
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+wcColumns, status, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not update work center status: %w", err)
+	}
+
+	switch {
+	case status == manufacturing.WorkCenterDown && current != manufacturing.WorkCenterDown:
+		// an event already open, e.g. a changeover, keeps counting instead
+		_, err := tx.Exec(`
+			INSERT INTO downtime_events (work_center_id, category, notes, reported_by, previous_status)
+			VALUES ($1, $2, 'work center set down', $3, $4)
+			ON CONFLICT (work_center_id) WHERE ended_at IS NULL DO NOTHING`,
+			id, manufacturing.DowntimeBreakdown, actorID, current)
+		if err != nil {
+			return nil, fmt.Errorf("could not log downtime: %w", err)
+		}
+	case current == manufacturing.WorkCenterDown && status != manufacturing.WorkCenterDown:
+		_, err := tx.Exec(`
+			UPDATE downtime_events
+			SET ended_at = NOW(), updated_at = NOW()
+			WHERE work_center_id = $1 AND category = $2 AND ended_at IS NULL`, id, manufacturing.DowntimeBreakdown)
+		if err != nil {
+			return nil, fmt.Errorf("could not end downtime: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &updated, nil
+}
+
+// lockWorkCenterTx locks a work center row and returns its status.
+func lockWorkCenterTx(tx *sql.Tx, id int) (string, error) {
+	var status string
+	if err := tx.QueryRow("SELECT status FROM work_centers WHERE id = $1 FOR UPDATE", id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return "", apperr.Newf(apperr.NotFound, "work center with id %d not found", id)
+		}
+		return "", fmt.Errorf("could not fetch work center: %w", err)
+	}
+	return status, nil
+}
+
+// DeleteWorkCenter removes a work center nothing is routed or scheduled to.
+func (p *Postgres) DeleteWorkCenter(id int) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var operations, workOrders int
+	err = tx.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM routing_operations WHERE work_center_id = $1),
+		       (SELECT COUNT(*) FROM work_orders WHERE work_center_id = $1)`, id).Scan(&operations, &workOrders)
+	if err != nil {
+		return fmt.Errorf("could not check work center usage: %w", err)
+	}
+	if operations > 0 || workOrders > 0 {
+		return fmt.Errorf("%w: work center %d is still used by %d routing operations and %d work orders",
+			manufacturing.ErrGuardFailed, id, operations, workOrders)
+	}
+
+	result, err := tx.Exec("DELETE FROM work_centers WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("could not delete work center: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return apperr.Newf(apperr.NotFound, "work center with id %d not found", id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+const downtimeColumns = `id, work_center_id, category, started_at, ended_at,
+	EXTRACT(EPOCH FROM COALESCE(ended_at, NOW()) - started_at) / 60, notes, reported_by, created_at, updated_at`
+
+func scanDowntimeEvent(row rowScanner) (types.DowntimeEvent, error) {
+	var ev types.DowntimeEvent
+	err := row.Scan(
+		&ev.ID,
+		&ev.WorkCenterID,
+		&ev.Category,
+		&ev.StartedAt,
+		&ev.EndedAt,
+		&ev.Minutes,
+		&ev.Notes,
+		&ev.ReportedBy,
+		&ev.CreatedAt,
+		&ev.UpdatedAt,
+	)
+	return ev, err
+}
+
+// CreateDowntimeEvent logs a stoppage. An event without an end stays open
+// until EndDowntimeEvent; a work center has at most one open event. An open
+// breakdown takes the work center down until it ends.
+func (p *Postgres) CreateDowntimeEvent(ev types.DowntimeEvent) (*types.DowntimeEvent, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockWorkCenterTx(tx, ev.WorkCenterID)
+	if err != nil {
+		return nil, err
+	}
+
+	// previous is what ending the breakdown restores
+	var previous *string
+	breakdown := ev.Category == manufacturing.DowntimeBreakdown && ev.EndedAt == nil
+	if breakdown {
+		if status == manufacturing.WorkCenterDown {
+			status = manufacturing.WorkCenterAvailable
+		}
+		previous = &status
+	}
+
+	created, err := scanDowntimeEvent(tx.QueryRow(`
+		INSERT INTO downtime_events (work_center_id, category, started_at, ended_at, notes, reported_by, previous_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+downtimeColumns,
+		ev.WorkCenterID, ev.Category, ev.StartedAt, ev.EndedAt, ev.Notes, ev.ReportedBy, previous))
+	if err != nil {
+		if violates(err, "downtime_events_open_idx") {
+			return nil, fmt.Errorf("%w: work center %d already has an open downtime event", manufacturing.ErrGuardFailed, ev.WorkCenterID)
+		}
+		return nil, fmt.Errorf("could not log downtime: %w", err)
+	}
+
+	if breakdown {
+		_, err := tx.Exec("UPDATE work_centers SET status = $1, updated_at = NOW() WHERE id = $2",
+			manufacturing.WorkCenterDown, ev.WorkCenterID)
+		if err != nil {
+			return nil, fmt.Errorf("could not update work center status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &created, nil
+}
+
+// EndDowntimeEvent closes an open downtime event at endedAt, or now. Ending
+// a breakdown gives a work center that is still down its previous status
+// back, or makes it available when the maintenance it was in has finished.
+func (p *Postgres) EndDowntimeEvent(workCenterID, id int, endedAt *time.Time) (*types.DowntimeEvent, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockWorkCenterTx(tx, workCenterID); err != nil {
+		return nil, err
+	}
+
+	var previous *string
+	err = tx.QueryRow(`
+		SELECT previous_status FROM downtime_events
+		WHERE id = $1 AND work_center_id = $2 AND ended_at IS NULL`, id, workCenterID).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("could not fetch downtime: %w", err)
+	}
+
+	ev, err := scanDowntimeEvent(tx.QueryRow(`
+		UPDATE downtime_events
+		SET ended_at = COALESCE($1, NOW()), updated_at = NOW()
+		WHERE id = $2 AND work_center_id = $3 AND ended_at IS NULL
+		RETURNING `+downtimeColumns, endedAt, id, workCenterID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "open downtime event with id %d not found", id)
+		}
+		if violates(err, "downtime_events_check") {
+			return nil, fmt.Errorf("%w: downtime cannot end before it started", manufacturing.ErrGuardFailed)
+		}
+		return nil, fmt.Errorf("could not end downtime: %w", err)
+	}
+
+	if previous != nil {
+		_, err := tx.Exec(`
+			UPDATE work_centers
+			SET status = CASE WHEN $1 = 'maintenance' AND NOT EXISTS (
+			                      SELECT 1 FROM maintenance_tasks WHERE work_center_id = $2 AND status = 'in_progress')
+			                  THEN 'available' ELSE $1 END,
+			    updated_at = NOW()
+			WHERE id = $2 AND status = 'down'`, *previous, workCenterID)
+		if err != nil {
+			return nil, fmt.Errorf("could not restore work center status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &ev, nil
+}
+
+// GetDowntimeEvents lists the downtime of a work center overlapping
+// [from, to), each bound optional.
+func (p *Postgres) GetDowntimeEvents(workCenterID int, from, to *time.Time) ([]types.DowntimeEvent, error) {
+	query := `
+		SELECT ` + downtimeColumns + `
+		FROM downtime_events
+		WHERE work_center_id = $1
+		  AND ($2::timestamp IS NULL OR COALESCE(ended_at, NOW()) > $2)
+		  AND ($3::timestamp IS NULL OR started_at < $3)
+		ORDER BY started_at ASC, id ASC
+	`
+
+	rows, err := p.db.Query(query, workCenterID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch downtime: %w", err)
+	}
+	defer rows.Close()
+
+	events := []types.DowntimeEvent{}
+	for rows.Next() {
+		ev, err := scanDowntimeEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan downtime event: %w", err)
+		}
+		events = append(events, ev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetWorkOrderRuns returns the work orders completed at a work center in
+// [from, to). The units of an MO are counted once, on its last regular work
+// order at the center; earlier operations and rework carry none.
+func (p *Postgres) GetWorkOrderRuns(workCenterID int, from, to time.Time) ([]types.WorkOrderRun, error) {
+	query := `
+		SELECT w.id, w.end_time, w.planned_minutes, w.actual_minutes,
+		       CASE WHEN w.rework_of_id IS NULL AND NOT EXISTS (
+		                SELECT 1 FROM work_orders l
+		                WHERE l.mo_id = w.mo_id
+		                  AND l.work_center_id = w.work_center_id
+		                  AND l.rework_of_id IS NULL
+		                  AND (l.sequence, l.id) > (w.sequence, w.id)
+		            ) THEN m.quantity ELSE 0 END
+		FROM work_orders w
+		JOIN manufacturing_orders m ON m.id = w.mo_id
+		WHERE w.work_center_id = $1
+		  AND w.status = 'completed'
+		  AND w.end_time >= $2 AND w.end_time < $3
+		ORDER BY w.end_time ASC
+	`
+
+	rows, err := p.db.Query(query, workCenterID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch work order runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []types.WorkOrderRun{}
+	for rows.Next() {
+		var r types.WorkOrderRun
+		if err := rows.Scan(&r.WorkOrderID, &r.EndTime, &r.PlannedMinutes, &r.ActualMinutes, &r.Units); err != nil {
+			return nil, fmt.Errorf("could not scan work order run: %w", err)
+		}
+		runs = append(runs, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return runs, nil
+}
+
+//-----------------work centers--Radiator-------------------------//
+
+//-----------------calendars-----Radiator-------------------------//
+
+// saveShiftsTx replaces the shifts of a pattern and makes it the only
+// default pattern when it is flagged as such.
+func saveShiftsTx(tx *sql.Tx, sp *types.ShiftPattern) error {
+	if sp.IsDefault {
+		_, err := tx.Exec("UPDATE shift_patterns SET is_default = false, updated_at = NOW() WHERE is_default AND id <> $1", sp.ID)
+		if err != nil {
+			return fmt.Errorf("could not clear default shift pattern: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE shift_patterns SET is_default = true WHERE id = $1", sp.ID); err != nil {
+			if violates(err, "shift_patterns_default_idx") {
+				return fmt.Errorf("%w: another shift pattern was made the default at the same time", manufacturing.ErrGuardFailed)
+			}
+			return fmt.Errorf("could not set default shift pattern: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM shifts WHERE shift_pattern_id = $1", sp.ID); err != nil {
+		return fmt.Errorf("could not clear shifts: %w", err)
+	}
+	for i := range sp.Shifts {
+		s := &sp.Shifts[i]
+		s.PatternID = sp.ID
+		err := tx.QueryRow(`
+			INSERT INTO shifts (shift_pattern_id, name, weekday, start_time, end_time)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id`, sp.ID, s.Name, s.Weekday, s.Start, s.End).Scan(&s.ID)
+		if err != nil {
+			return fmt.Errorf("could not save shift: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *Postgres) CreateShiftPattern(sp types.ShiftPattern) (*types.ShiftPattern, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO shift_patterns (name)
+		VALUES ($1)
+		RETURNING id`, sp.Name).Scan(&sp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not create shift pattern: %w", err)
+	}
+	if err := saveShiftsTx(tx, &sp); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return p.GetShiftPatternByID(sp.ID)
+}
+
+// UpdateShiftPattern renames a pattern and replaces its shifts. Clearing
+// is_default on the default pattern leaves the plant without one.
+func (p *Postgres) UpdateShiftPattern(sp types.ShiftPattern) (*types.ShiftPattern, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE shift_patterns
+		SET name = $1, is_default = is_default AND $2, updated_at = NOW()
+		WHERE id = $3`, sp.Name, sp.IsDefault, sp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not update shift pattern: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return nil, apperr.Newf(apperr.NotFound, "shift pattern with id %d not found", sp.ID)
+	}
+	if err := saveShiftsTx(tx, &sp); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return p.GetShiftPatternByID(sp.ID)
+}
+
+// getShiftPatterns loads the patterns matching where, with their shifts in
+// week order.
+func getShiftPatterns(q queryer, where string, args ...any) ([]types.ShiftPattern, error) {
+	rows, err := q.Query(`SELECT id, name, is_default, created_at, updated_at FROM shift_patterns `+where+` ORDER BY id ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch shift patterns: %w", err)
+	}
+	defer rows.Close()
+
+	patterns := []types.ShiftPattern{}
+	index := map[int]int{}
+	var ids []int64
+	for rows.Next() {
+		sp := types.ShiftPattern{Shifts: []types.Shift{}}
+		if err := rows.Scan(&sp.ID, &sp.Name, &sp.IsDefault, &sp.CreatedAt, &sp.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan shift pattern: %w", err)
+		}
+		index[sp.ID] = len(patterns)
+		ids = append(ids, int64(sp.ID))
+		patterns = append(patterns, sp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	if len(patterns) == 0 {
+		return patterns, nil
+	}
+
+	shiftRows, err := q.Query(`
+		SELECT id, shift_pattern_id, name, weekday, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM shifts
+		WHERE shift_pattern_id = ANY($1)
+		ORDER BY weekday ASC, start_time ASC`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch shifts: %w", err)
+	}
+	defer shiftRows.Close()
+
+	for shiftRows.Next() {
+		var s types.Shift
+		if err := shiftRows.Scan(&s.ID, &s.PatternID, &s.Name, &s.Weekday, &s.Start, &s.End); err != nil {
+			return nil, fmt.Errorf("could not scan shift: %w", err)
+		}
+		sp := &patterns[index[s.PatternID]]
+		sp.Shifts = append(sp.Shifts, s)
+	}
+	if err := shiftRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return patterns, nil
+}
+
+func (p *Postgres) GetShiftPatterns() ([]types.ShiftPattern, error) {
+	return getShiftPatterns(p.db, "")
+}
+
+func (p *Postgres) GetShiftPatternByID(id int) (*types.ShiftPattern, error) {
+	patterns, err := getShiftPatterns(p.db, "WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, apperr.Newf(apperr.NotFound, "shift pattern with id %d not found", id)
+	}
+	return &patterns[0], nil
+}
+
+func (p *Postgres) DeleteShiftPattern(id int) error {
+	result, err := p.db.Exec("DELETE FROM shift_patterns WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("could not delete shift pattern: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return apperr.Newf(apperr.NotFound, "shift pattern with id %d not found", id)
+	}
+	return nil
+}
+
+// SetWorkCenterShiftPattern puts a work center on a shift pattern, or back
+// on the plant default when patternID is nil.
+func (p *Postgres) SetWorkCenterShiftPattern(workCenterID int, patternID *int) (*types.WorkCenter, error) {
+	if patternID != nil {
+		if _, err := p.GetShiftPatternByID(*patternID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE work_centers
+		SET shift_pattern_id = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING ` + wcColumns
+
+	wc, err := scanWorkCenter(p.db.QueryRow(query, patternID, workCenterID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", workCenterID)
+		}
+		return nil, fmt.Errorf("could not update work center: %w", err)
+	}
+	return &wc, nil
+}
+
+func (p *Postgres) CreateHoliday(h types.Holiday) (*types.Holiday, error) {
+	query := `
+		INSERT INTO holidays (date, work_center_id, name)
+		VALUES ($1, $2, $3)
+		RETURNING id, date, work_center_id, name, created_at
+	`
+
+	var created types.Holiday
+	err := p.db.QueryRow(query, h.Date, h.WorkCenterID, h.Name).Scan(
+		&created.ID, &created.Date, &created.WorkCenterID, &created.Name, &created.CreatedAt)
+	if err != nil {
+		if violates(err, "holidays_work_center_id_fkey") {
+			return nil, apperr.Newf(apperr.NotFound, "work center with id %d not found", *h.WorkCenterID)
+		}
+		if violates(err, "holidays_date_idx") {
+			return nil, fmt.Errorf("%w: %s is already a holiday", manufacturing.ErrGuardFailed, h.Date.Format(time.DateOnly))
+		}
+		return nil, fmt.Errorf("could not create holiday: %w", err)
+	}
+	return &created, nil
+}
+
+// GetHolidays lists holidays between from and to, each optional. With a
+// work center it returns the plant-wide holidays and that center's own;
+// without one, all of them.
+func (p *Postgres) GetHolidays(workCenterID *int, from, to *time.Time) ([]types.Holiday, error) {
+	return getHolidays(p.db, workCenterID, from, to)
+}
+
+func getHolidays(q queryer, workCenterID *int, from, to *time.Time) ([]types.Holiday, error) {
+	query := `
+		SELECT id, date, work_center_id, name, created_at
+		FROM holidays
+		WHERE ($1::int IS NULL OR work_center_id IS NULL OR work_center_id = $1)
+		  AND ($2::date IS NULL OR date >= $2)
+		  AND ($3::date IS NULL OR date <= $3)
+		ORDER BY date ASC, work_center_id ASC NULLS FIRST
+	`
+
+	rows, err := q.Query(query, workCenterID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch holidays: %w", err)
+	}
+	defer rows.Close()
+
+	holidays := []types.Holiday{}
+	for rows.Next() {
+		var h types.Holiday
+		if err := rows.Scan(&h.ID, &h.Date, &h.WorkCenterID, &h.Name, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return holidays, nil
+}
+
+func (p *Postgres) DeleteHoliday(id int) error {
+	result, err := p.db.Exec("DELETE FROM holidays WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("could not delete holiday: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return apperr.Newf(apperr.NotFound, "holiday with id %d not found", id)
+	}
+	return nil
+}
+
+// GetWorkCenterCalendar returns what a work center's capacity is computed
+// from: the center, the shift pattern it works to (its own or the plant
+// default; nil when there is neither) and its holidays between from and to.
+func (p *Postgres) GetWorkCenterCalendar(workCenterID int, from, to time.Time) (*types.WorkCenter, *types.ShiftPattern, []types.Holiday, error) {
+	wc, err := p.GetWorkCenterByID(workCenterID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	where, args := "WHERE is_default", []any{}
+	if wc.ShiftPatternID != nil {
+		where, args = "WHERE id = $1", []any{*wc.ShiftPatternID}
+	}
+	patterns, err := getShiftPatterns(p.db, where, args...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var pattern *types.ShiftPattern
+	if len(patterns) > 0 {
+		pattern = &patterns[0]
+	}
+
+	holidays, err := p.GetHolidays(&workCenterID, &from, &to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return wc, pattern, holidays, nil
+}
+
+// scheduleHorizon is how many days timelines reach from where work is
+// scheduled from.
+const scheduleHorizon = 366
+
+// midnight returns the start of the day t falls on.
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// workCenterWindows lays out the shifts a work center works in [from, to):
+// its own pattern, the plant default, or calendar.DayShifts without either,
+// less holidays. A nil work center stands for work done outside any center,
+// which keeps the plant default and plant-wide holidays.
+func workCenterWindows(q queryer, workCenterID *int, from, to time.Time) ([]calendar.Window, error) {
+	patterns, err := getShiftPatterns(q, `WHERE id = COALESCE(
+		(SELECT shift_pattern_id FROM work_centers WHERE id = $1::int),
+		(SELECT id FROM shift_patterns WHERE is_default))`, workCenterID)
+	if err != nil {
+		return nil, err
+	}
+	pattern := calendar.DayShifts()
+	if len(patterns) > 0 {
+		pattern = &patterns[0]
+	}
+
+	// night shifts of the day before may run into from
+	first, last := midnight(from).AddDate(0, 0, -1), midnight(to)
+	holidays, err := getHolidays(q, workCenterID, &first, &last)
+	if err != nil {
+		return nil, err
+	}
+	if workCenterID == nil {
+		plant := holidays[:0]
+		for _, h := range holidays {
+			if h.WorkCenterID == nil {
+				plant = append(plant, h)
+			}
+		}
+		holidays = plant
+	}
+
+	windows, err := calendar.Windows(pattern, holidays, first, last)
+	if err != nil {
+		return nil, err
+	}
+	var out []calendar.Window
+	for _, w := range windows {
+		if w.End.After(from) && w.Start.Before(to) {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+// workCenterTimeline is the working time of a work center in [from, to)
+// less its open maintenance. Planned maintenance that is overdue blocks the
+// time from now until it is started.
+func workCenterTimeline(q queryer, workCenterID *int, from, to, now time.Time) (calendar.Timeline, error) {
+	windows, err := workCenterWindows(q, workCenterID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if workCenterID == nil {
+		return calendar.NewTimeline(windows, nil), nil
+	}
+	tasks, err := queryMaintenanceTasks(q, `
+		SELECT `+taskColumns+`
+		FROM maintenance_tasks
+		WHERE work_center_id = $1 AND status <> 'done'
+		  AND due_at < $3 AND (planned_end > $2 OR status = 'planned')
+		ORDER BY due_at ASC`, *workCenterID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.NewTimeline(windows, calendar.Outstanding(tasks, now)), nil
+}
+
+// workStepTimelines loads the timelines of the work centers steps run at
+// for [from, to).
+func workStepTimelines(q queryer, steps []types.WorkStep, from, to, now time.Time) (manufacturing.Timelines, error) {
+	byCenter := map[int]calendar.Timeline{}
+	plant, err := workCenterTimeline(q, nil, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range steps {
+		if s.WorkCenterID == nil {
+			continue
+		}
+		if _, ok := byCenter[*s.WorkCenterID]; ok {
+			continue
+		}
+		t, err := workCenterTimeline(q, s.WorkCenterID, from, to, now)
+		if err != nil {
+			return nil, err
+		}
+		byCenter[*s.WorkCenterID] = t
+	}
+	return func(workCenterID *int) calendar.Timeline {
+		if workCenterID == nil {
+			return plant
+		}
+		return byCenter[*workCenterID]
+	}, nil
+}
+
+// GetWorkStepTimelines returns the working time of the work centers steps run
+// at, from now until the given time.
+func (p *Postgres) GetWorkStepTimelines(steps []types.WorkStep, until time.Time) (manufacturing.Timelines, error) {
+	now := time.Now()
+	return workStepTimelines(p.db, steps, now, until, now)
+}
+
+//-----------------calendars-----Radiator-------------------------//
+
+//-----------------maintenance---Radiator-------------------------//
+
+// planColumns reads a maintenance plan aliased mp, with the run time logged
+// at its work center since it was last serviced (or created).
+const planColumns = `mp.id, mp.work_center_id, mp.name, mp.description, mp.trigger, mp.interval_days, mp.interval_hours,
+	mp.duration_minutes, mp.active, mp.last_done_at,
+	(SELECT COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(t.ended_at, NOW()) - GREATEST(t.started_at, COALESCE(mp.last_done_at, mp.created_at)))) / 3600, 0)
+	 FROM work_order_time_logs t
+	 JOIN work_orders w ON w.id = t.work_order_id
+	 WHERE w.work_center_id = mp.work_center_id
+	   AND COALESCE(t.ended_at, NOW()) > COALESCE(mp.last_done_at, mp.created_at)),
+	mp.created_at, mp.updated_at`
+
+func scanMaintenancePlan(row rowScanner) (types.MaintenancePlan, error) {
+	var mp types.MaintenancePlan
+	err := row.Scan(
+		&mp.ID,
+		&mp.WorkCenterID,
+		&mp.Name,
+		&mp.Description,
+		&mp.Trigger,
+		&mp.IntervalDays,
+		&mp.IntervalHours,
+		&mp.DurationMinutes,
+		&mp.Active,
+		&mp.LastDoneAt,
+		&mp.RunHours,
+		&mp.CreatedAt,
+		&mp.UpdatedAt,
+	)
+	return mp, err
+}
+
+const taskColumns = `id, plan_id, work_center_id, title, status, due_at, planned_end, started_at, completed_at,
+	completed_by, run_hours, notes, created_at, updated_at`
+
+func scanMaintenanceTask(row rowScanner) (types.MaintenanceTask, error) {
+	var t types.MaintenanceTask
+	err := row.Scan(
+		&t.ID,
+		&t.PlanID,
+		&t.WorkCenterID,
+		&t.Title,
+		&t.Status,
+		&t.DueAt,
+		&t.PlannedEnd,
+		&t.StartedAt,
+		&t.CompletedAt,
+		&t.CompletedBy,
+		&t.RunHours,
+		&t.Notes,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	return t, err
+}
+
+func queryMaintenanceTasks(q queryer, query string, args ...any) ([]types.MaintenanceTask, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch maintenance tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []types.MaintenanceTask{}
+	for rows.Next() {
+		t, err := scanMaintenanceTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan maintenance task: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// generateMaintenanceTasksTx gives every active plan that has no open task
+// and is due its next task: calendar plans as soon as they are serviced,
+// run-hour plans once enough run time has been logged. With a work center
+// only that center's plans are considered.
+func generateMaintenanceTasksTx(tx *sql.Tx, workCenterID *int) ([]types.MaintenanceTask, error) {
+	rows, err := tx.Query(`
+		SELECT `+planColumns+`
+		FROM maintenance_plans mp
+		WHERE mp.active
+		  AND ($1::int IS NULL OR mp.work_center_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM maintenance_tasks t WHERE t.plan_id = mp.id AND t.status <> 'done')
+		ORDER BY mp.id ASC`, workCenterID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch maintenance plans: %w", err)
+	}
+	var plans []types.MaintenancePlan
+	for rows.Next() {
+		mp, err := scanMaintenancePlan(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan maintenance plan: %w", err)
+		}
+		plans = append(plans, mp)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	created := []types.MaintenanceTask{}
+	for _, mp := range plans {
+		// a nil due date lets the database stamp run-hour tasks with NOW()
+		var due *time.Time
+		switch mp.Trigger {
+		case manufacturing.TriggerCalendar:
+			base := mp.CreatedAt
+			if mp.LastDoneAt != nil {
+				base = *mp.LastDoneAt
+			}
+			d := manufacturing.CalendarDue(base, *mp.IntervalDays)
+			due = &d
+		case manufacturing.TriggerRunHours:
+			if !manufacturing.RunHoursDue(mp.RunHours, *mp.IntervalHours) {
+				continue
+			}
+		}
+
+		t, err := scanMaintenanceTask(tx.QueryRow(`
+			INSERT INTO maintenance_tasks (plan_id, work_center_id, title, due_at, planned_end)
+			VALUES ($1, $2, $3, COALESCE($4, NOW()), COALESCE($4, NOW()) + make_interval(mins => $5))
+			ON CONFLICT (plan_id) WHERE status <> 'done' DO NOTHING
+			RETURNING `+taskColumns,
+			mp.ID, mp.WorkCenterID, mp.Name, due, mp.DurationMinutes))
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not create maintenance task: %w", err)
+		}
+		created = append(created, t)
+	}
+	return created, nil
+}
+
+// CreateMaintenancePlan adds a plan to a machine work center and schedules
+// its first task when it is due.
+func (p *Postgres) CreateMaintenancePlan(plan types.MaintenancePlan) (*types.MaintenancePlan, error) {
+	wc, err := p.GetWorkCenterByID(plan.WorkCenterID)
+	if err != nil {
+		return nil, err
+	}
+	if wc.Type != manufacturing.WorkCenterMachine {
+		return nil, fmt.Errorf("%w: maintenance plans are for machines, work center %d is a %s",
+			manufacturing.ErrGuardFailed, wc.ID, wc.Type)
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO maintenance_plans (work_center_id, name, description, trigger, interval_days, interval_hours,
+		                               duration_minutes, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		plan.WorkCenterID, plan.Name, plan.Description, plan.Trigger, plan.IntervalDays, plan.IntervalHours,
+		plan.DurationMinutes, plan.Active).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("could not create maintenance plan: %w", err)
+	}
+	if _, err := generateMaintenanceTasksTx(tx, &plan.WorkCenterID); err != nil {
+		return nil, err
+	}
+
+	created, err := scanMaintenancePlan(tx.QueryRow(`SELECT `+planColumns+` FROM maintenance_plans mp WHERE mp.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch maintenance plan: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &created, nil
+}
+
+// UpdateMaintenancePlan changes a plan's schedule. A task already planned
+// keeps its date; the change applies from the next one.
+func (p *Postgres) UpdateMaintenancePlan(plan types.MaintenancePlan) (*types.MaintenancePlan, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var workCenterID int
+	err = tx.QueryRow(`
+		UPDATE maintenance_plans
+		SET name = $1, description = $2, trigger = $3, interval_days = $4, interval_hours = $5,
+		    duration_minutes = $6, active = $7, updated_at = NOW()
+		WHERE id = $8
+		RETURNING work_center_id`,
+		plan.Name, plan.Description, plan.Trigger, plan.IntervalDays, plan.IntervalHours,
+		plan.DurationMinutes, plan.Active, plan.ID).Scan(&workCenterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "maintenance plan with id %d not found", plan.ID)
+		}
+		return nil, fmt.Errorf("could not update maintenance plan: %w", err)
+	}
+	if _, err := generateMaintenanceTasksTx(tx, &workCenterID); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanMaintenancePlan(tx.QueryRow(`SELECT `+planColumns+` FROM maintenance_plans mp WHERE mp.id = $1`, plan.ID))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch maintenance plan: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &updated, nil
+}
+
+func (p *Postgres) GetMaintenancePlans(workCenterID int) ([]types.MaintenancePlan, error) {
+	if _, err := p.GetWorkCenterByID(workCenterID); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.Query(`SELECT `+planColumns+` FROM maintenance_plans mp WHERE mp.work_center_id = $1 ORDER BY mp.id ASC`, workCenterID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch maintenance plans: %w", err)
+	}
+	defer rows.Close()
+
+	plans := []types.MaintenancePlan{}
+	for rows.Next() {
+		mp, err := scanMaintenancePlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan maintenance plan: %w", err)
+		}
+		plans = append(plans, mp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return plans, nil
+}
+
+// DeleteMaintenancePlan removes a plan and its open task. Completed tasks
+// stay in the work center's history.
+func (p *Postgres) DeleteMaintenancePlan(id int) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM maintenance_tasks WHERE plan_id = $1 AND status = 'planned'", id); err != nil {
+		return fmt.Errorf("could not delete planned maintenance: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM maintenance_plans WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("could not delete maintenance plan: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return apperr.Newf(apperr.NotFound, "maintenance plan with id %d not found", id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GenerateMaintenanceTasks creates the tasks that have come due across all
+// work centers, for a periodic job or a manager to trigger.
+func (p *Postgres) GenerateMaintenanceTasks() ([]types.MaintenanceTask, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := generateMaintenanceTasksTx(tx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+// GetMaintenanceTasks lists maintenance tasks, optionally of one work center
+// and in one status. Done tasks come most recent first, open ones by due date.
+func (p *Postgres) GetMaintenanceTasks(workCenterID *int, status string) ([]types.MaintenanceTask, error) {
+	return queryMaintenanceTasks(p.db, `
+		SELECT `+taskColumns+`
+		FROM maintenance_tasks
+		WHERE ($1::int IS NULL OR work_center_id = $1)
+		  AND ($2::text = '' OR status = $2)
+		ORDER BY status = 'done' ASC, CASE WHEN status = 'done' THEN completed_at END DESC, due_at ASC, id ASC`,
+		workCenterID, status)
+}
+
+// GetMaintenanceWindows returns the open maintenance of a work center whose
+// planned time overlaps [from, to).
+func (p *Postgres) GetMaintenanceWindows(workCenterID int, from, to time.Time) ([]types.MaintenanceTask, error) {
+	return queryMaintenanceTasks(p.db, `
+		SELECT `+taskColumns+`
+		FROM maintenance_tasks
+		WHERE work_center_id = $1 AND status <> 'done'
+		  AND due_at < $3 AND planned_end > $2
+		ORDER BY due_at ASC`, workCenterID, from, to)
+}
+
+// RescheduleMaintenanceTask moves a planned task, keeping its length.
+func (p *Postgres) RescheduleMaintenanceTask(id int, dueAt time.Time) (*types.MaintenanceTask, error) {
+	query := `
+		UPDATE maintenance_tasks
+		SET planned_end = $1 + (planned_end - due_at), due_at = $1, updated_at = NOW()
+		WHERE id = $2 AND status = 'planned'
+		RETURNING ` + taskColumns
+
+	t, err := scanMaintenanceTask(p.db.QueryRow(query, dueAt, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "planned maintenance task with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not reschedule maintenance task: %w", err)
+	}
+	return &t, nil
+}
+
+// StartMaintenanceTask takes a work center into maintenance. The center has
+// to be available (or already in maintenance for another task) and no work
+// order may be running there; a center that is down stays down until its
+// breakdown is ended.
+func (p *Postgres) StartMaintenanceTask(id int) (*types.MaintenanceTask, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	t, err := scanMaintenanceTask(tx.QueryRow(`SELECT `+taskColumns+` FROM maintenance_tasks WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "maintenance task with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch maintenance task: %w", err)
+	}
+	if t.Status != manufacturing.TaskPlanned {
+		return nil, fmt.Errorf("%w: maintenance task %d is %s", manufacturing.ErrIllegalTransition, id, t.Status)
+	}
+
+	status, err := lockWorkCenterTx(tx, t.WorkCenterID)
+	if err != nil {
+		return nil, err
+	}
+	if status != manufacturing.WorkCenterAvailable && status != manufacturing.WorkCenterMaintenance {
+		return nil, fmt.Errorf("%w: work center %d is %s", manufacturing.ErrGuardFailed, t.WorkCenterID, status)
+	}
+
+	var running int
+	err = tx.QueryRow("SELECT COUNT(*) FROM work_orders WHERE work_center_id = $1 AND status = 'in_progress'", t.WorkCenterID).Scan(&running)
+	if err != nil {
+		return nil, fmt.Errorf("could not check running work orders: %w", err)
+	}
+	if running > 0 {
+		return nil, fmt.Errorf("%w: %d work orders are still running at work center %d",
+			manufacturing.ErrGuardFailed, running, t.WorkCenterID)
+	}
+
+	_, err = tx.Exec("UPDATE work_centers SET status = 'maintenance', updated_at = NOW() WHERE id = $1", t.WorkCenterID)
+	if err != nil {
+		return nil, fmt.Errorf("could not update work center status: %w", err)
+	}
+	started, err := scanMaintenanceTask(tx.QueryRow(`
+		UPDATE maintenance_tasks
+		SET status = 'in_progress', started_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+taskColumns, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not start maintenance task: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &started, nil
+}
+
+// CompleteMaintenanceTask records maintenance as done, resets its plan's
+// interval, returns the work center to service and plans the next task.
+func (p *Postgres) CompleteMaintenanceTask(id, actorID int, notes string) (*types.MaintenanceTask, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	t, err := scanMaintenanceTask(tx.QueryRow(`SELECT `+taskColumns+` FROM maintenance_tasks WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Newf(apperr.NotFound, "maintenance task with id %d not found", id)
+		}
+		return nil, fmt.Errorf("could not fetch maintenance task: %w", err)
+	}
+	if t.Status != manufacturing.TaskInProgress {
+		return nil, fmt.Errorf("%w: maintenance task %d is %s", manufacturing.ErrIllegalTransition, id, t.Status)
+	}
+
+	var runHours float64
+	if t.PlanID != nil {
+		mp, err := scanMaintenancePlan(tx.QueryRow(`SELECT `+planColumns+` FROM maintenance_plans mp WHERE mp.id = $1`, *t.PlanID))
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("could not fetch maintenance plan: %w", err)
+		}
+		runHours = mp.RunHours
+		_, err = tx.Exec("UPDATE maintenance_plans SET last_done_at = NOW(), updated_at = NOW() WHERE id = $1", *t.PlanID)
+		if err != nil {
+			return nil, fmt.Errorf("could not update maintenance plan: %w", err)
+		}
+	}
+
+	done, err := scanMaintenanceTask(tx.QueryRow(`
+		UPDATE maintenance_tasks
+		SET status = 'done', completed_at = NOW(), completed_by = $1, run_hours = $2, notes = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING `+taskColumns, actorID, math.Round(runHours*100)/100, notes, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not complete maintenance task: %w", err)
+	}
+
+	// another job may still hold the machine
+	_, err = tx.Exec(`
+		UPDATE work_centers
+		SET status = 'available', updated_at = NOW()
+		WHERE id = $1 AND status = 'maintenance'
+		  AND NOT EXISTS (SELECT 1 FROM maintenance_tasks WHERE work_center_id = $1 AND status = 'in_progress')`, t.WorkCenterID)
+	if err != nil {
+		return nil, fmt.Errorf("could not update work center status: %w", err)
+	}
+
+	if _, err := generateMaintenanceTasksTx(tx, &t.WorkCenterID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return &done, nil
+}
+
+//-----------------maintenance---Radiator-------------------------//
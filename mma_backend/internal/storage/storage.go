@@ -1,6 +1,11 @@
 package storage
 
-import "mma_api/internal/types"
+import (
+	"time"
+
+	"mma_api/internal/manufacturing"
+	"mma_api/internal/types"
+)
 
 type Storage interface {
 	CreateUser(name, role, email, password string) (*types.User, error)
@@ -9,7 +14,110 @@ type Storage interface {
 	GetUserByID(id int) (*types.User, error)
 	DeleteUser(id int) error
 	GetUserByEmail(email string) (*types.User, error)
-	CreateProduct(name, description, category, unit string) (*types.Product, error)
+	CreateProduct(product types.Product) (*types.Product, error)
+	UpdateProduct(product types.Product) (*types.Product, error)
 	GetProductById(id int) (*types.Product, error)
-	CreateBoM(productID, componentID int, quantity float64, operationName string) (*types.BoM, error)
+	CreateBoM(line types.BoM) (*types.BoM, error)
+	GetBoM(productID int) ([]types.BoM, error)
+	GetBoMVersion(productID, version int) ([]types.BoM, error)
+	GetBoMYield(productID, version int) (float64, error)
+	SetBoMYield(productID, version int, yieldPercent float64) error
+	GetOperationCosts(productID int) ([]types.OperationCost, error)
+	SaveProductCost(cost types.ProductCost) (*types.ProductCost, error)
+	GetProductCostHistory(productID int) ([]types.ProductCost, error)
+	CreateRoutingOperation(op types.RoutingOperation) (*types.RoutingOperation, error)
+	UpdateRoutingOperation(op types.RoutingOperation) (*types.RoutingOperation, error)
+	DeleteRoutingOperation(productID, id int) error
+	GetRouting(productID int) ([]types.RoutingOperation, error)
+	CreateBoMAlternate(productID int, alt types.BoMAlternate) (*types.BoMAlternate, error)
+	GetBoMAlternates(bomID int) ([]types.BoMAlternate, error)
+	DeleteBoMAlternate(bomID, id int) error
+	GetStockBalance(productID int) (float64, error)
+	GetFreeStock(productID, excludeMOID int) (float64, error)
+	CreateMO(mo types.ManufacturingOrder) (*types.ManufacturingOrder, error)
+	GetMOs(filter types.MOFilter) ([]types.MOSummary, error)
+	GetMOByID(id int) (*types.ManufacturingOrder, error)
+	UpdateMO(mo types.ManufacturingOrder) (*types.ManufacturingOrder, error)
+	TransitionMO(id int, action string, actorID int) (*types.ManufacturingOrder, error)
+	ProduceMO(id, quantity int, output types.ProductionOutput) (*types.ManufacturingOrder, error)
+	CompleteMO(id int, closeShort, backorder bool, output types.ProductionOutput, actorID int) (*types.ManufacturingOrder, *types.ManufacturingOrder, error)
+	SplitMO(id, quantity int) (*types.ManufacturingOrder, *types.ManufacturingOrder, error)
+	GetChildMOs(parentID int) ([]types.ManufacturingOrder, error)
+	GetMOTransitions(moID int) ([]types.MOTransition, error)
+	GetWorkOrdersByMO(moID int) ([]types.WorkOrder, error)
+	ReorderWorkOrder(id int, afterID, beforeID *int) (*types.WorkOrder, error)
+	CreateWorkCenter(wc types.WorkCenter) (*types.WorkCenter, error)
+	GetWorkCenters() ([]types.WorkCenter, error)
+	GetWorkCenterByID(id int) (*types.WorkCenter, error)
+	UpdateWorkCenter(wc types.WorkCenter) (*types.WorkCenter, error)
+	SetWorkCenterRates(id int, labourRate, overheadRate float64) (*types.WorkCenter, error)
+	SetWorkCenterStatus(id int, status string, actorID int) (*types.WorkCenter, error)
+	DeleteWorkCenter(id int) error
+	CreateShiftPattern(sp types.ShiftPattern) (*types.ShiftPattern, error)
+	UpdateShiftPattern(sp types.ShiftPattern) (*types.ShiftPattern, error)
+	GetShiftPatterns() ([]types.ShiftPattern, error)
+	GetShiftPatternByID(id int) (*types.ShiftPattern, error)
+	DeleteShiftPattern(id int) error
+	SetWorkCenterShiftPattern(workCenterID int, patternID *int) (*types.WorkCenter, error)
+	CreateHoliday(h types.Holiday) (*types.Holiday, error)
+	GetHolidays(workCenterID *int, from, to *time.Time) ([]types.Holiday, error)
+	DeleteHoliday(id int) error
+	GetWorkCenterCalendar(workCenterID int, from, to time.Time) (*types.WorkCenter, *types.ShiftPattern, []types.Holiday, error)
+	CreateDowntimeEvent(ev types.DowntimeEvent) (*types.DowntimeEvent, error)
+	EndDowntimeEvent(workCenterID, id int, endedAt *time.Time) (*types.DowntimeEvent, error)
+	GetDowntimeEvents(workCenterID int, from, to *time.Time) ([]types.DowntimeEvent, error)
+	GetWorkOrderRuns(workCenterID int, from, to time.Time) ([]types.WorkOrderRun, error)
+	GetWorkCenterScrap(workCenterID int, from, to time.Time) ([]types.ScrapRecord, error)
+	CreateMaintenancePlan(plan types.MaintenancePlan) (*types.MaintenancePlan, error)
+	UpdateMaintenancePlan(plan types.MaintenancePlan) (*types.MaintenancePlan, error)
+	GetMaintenancePlans(workCenterID int) ([]types.MaintenancePlan, error)
+	DeleteMaintenancePlan(id int) error
+	GenerateMaintenanceTasks() ([]types.MaintenanceTask, error)
+	GetMaintenanceTasks(workCenterID *int, status string) ([]types.MaintenanceTask, error)
+	GetMaintenanceWindows(workCenterID int, from, to time.Time) ([]types.MaintenanceTask, error)
+	GetWorkStepTimelines(steps []types.WorkStep, until time.Time) (manufacturing.Timelines, error)
+	RescheduleMaintenanceTask(id int, dueAt time.Time) (*types.MaintenanceTask, error)
+	StartMaintenanceTask(id int) (*types.MaintenanceTask, error)
+	CompleteMaintenanceTask(id, actorID int, notes string) (*types.MaintenanceTask, error)
+	GetWorkCenterQueue(workCenterID int) ([]types.QueueEntry, error)
+	GetWorkOrderByID(id int) (*types.WorkOrder, error)
+	WorkOrderAction(id int, action string, actorID int, isManager bool) (*types.WorkOrder, error)
+	GetWorkOrderTimeLogs(workOrderID int) ([]types.WorkOrderTimeLog, error)
+	ClockIn(workOrderID, workerID int) (*types.LabourEntry, error)
+	ClockOut(workOrderID, workerID int) (*types.LabourEntry, error)
+	GetWorkOrderLabour(workOrderID int) (*types.WorkOrderLabour, error)
+	GetTimesheet(workerID int, period string, from, to time.Time) ([]types.TimesheetLine, error)
+	GetWorkerTasks(workerID int) ([]types.Task, error)
+	AddQualification(workerID, workCenterID int) (*types.WorkerQualification, error)
+	GetQualifications(workerID int) ([]types.WorkerQualification, error)
+	DeleteQualification(workerID, workCenterID int) error
+	CreateSkill(skill types.Skill) (*types.Skill, error)
+	GetSkills() ([]types.Skill, error)
+	DeleteSkill(id int) error
+	SetUserSkill(us types.UserSkill) (*types.UserSkill, error)
+	GetUserSkills(userID int) ([]types.UserSkill, error)
+	DeleteUserSkill(userID, skillID int) error
+	SetWorkCenterSkills(workCenterID int, skillIDs []int) ([]types.Skill, error)
+	GetWorkCenterSkills(workCenterID int) ([]types.Skill, error)
+	SetOperationSkills(productID, operationID int, skillIDs []int) ([]types.Skill, error)
+	GetOperationSkills(productID, operationID int) ([]types.Skill, error)
+	GetExpiringCertifications(days int) ([]types.ExpiringCertification, error)
+	AssignWorkOrder(id int, workerID *int) (*types.WorkOrder, error)
+	GetMODependencies(moID int) ([]types.WorkOrderDependency, error)
+	AddDependency(workOrderID, predecessorID int) (*types.WorkOrderDependency, error)
+	DeleteDependency(workOrderID, predecessorID int) error
+	CreateScrapReason(reason types.ScrapReason) (*types.ScrapReason, error)
+	UpdateScrapReason(reason types.ScrapReason) (*types.ScrapReason, error)
+	GetScrapReasons() ([]types.ScrapReason, error)
+	ReportScrap(rec types.ScrapRecord) (*types.ScrapRecord, error)
+	GetScrapRecords(workOrderID int) ([]types.ScrapRecord, error)
+	GetScrapReport(groupBy string, from, to *time.Time) ([]types.ScrapReportLine, error)
+	SetMOPriority(id, priority int) (*types.ManufacturingOrder, error)
+	ReserveMaterials(moID int) ([]types.MaterialReservation, error)
+	GetMOReservations(moID int) ([]types.MaterialReservation, error)
+	ConsumeMaterial(moID, componentID int, quantity float64, lotNumber, serialNumber string) ([]types.Inventory, error)
+	GetMOMovements(moID int) ([]types.Inventory, error)
+	PostMovement(m types.Inventory) ([]types.Inventory, error)
+	GetLotBalances(productID int) ([]types.LotBalance, error)
+	GetTrackedMovements(productID int, lotNumber, serialNumber, movementType string) ([]types.Inventory, error)
 }
@@ -0,0 +1,173 @@
+package trace
+
+import (
+	"fmt"
+	"mma_api/internal/apperr"
+	"mma_api/internal/types"
+)
+
+// How units of a product are identified in stock.
+const (
+	TrackingNone   = "none"
+	TrackingLot    = "lot"
+	TrackingSerial = "serial"
+)
+
+// ErrTracking is returned when a movement does not carry the lot or serial
+// number its product requires, or names one that is not in stock.
+var ErrTracking = apperr.New(apperr.Conflict, "lot/serial tracking violation")
+
+type Source interface {
+	GetProductById(id int) (*types.Product, error)
+	// GetTrackedMovements returns the production movements (reference "mo")
+	// of one lot or serial number of a product.
+	GetTrackedMovements(productID int, lotNumber, serialNumber, movementType string) ([]types.Inventory, error)
+	GetMOMovements(moID int) ([]types.Inventory, error)
+}
+
+// Node is one lot or serial number in a genealogy. Children are the lots that
+// went into it (backward) or the lots it went into (forward).
+type Node struct {
+	ProductID    int     `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	LotNumber    *string `json:"lot_number,omitempty"`
+	SerialNumber *string `json:"serial_number,omitempty"`
+	// MOID is the order that consumed (backward) or produced (forward) the node.
+	MOID     *int    `json:"mo_id,omitempty"`
+	Quantity float64 `json:"quantity"`
+	Children []Node  `json:"children"`
+}
+
+// Backward answers "which lots are in this serial": it follows the orders
+// that produced the lot down to the tracked components they consumed, level
+// by level.
+func Backward(src Source, productID int, lotNumber, serialNumber string) (*Node, error) {
+	t := tracer{src: src, names: map[int]string{}}
+	return t.root(productID, lotNumber, serialNumber, "IN", "OUT")
+}
+
+// Forward answers "which finished serials contain this lot": it follows the
+// orders that consumed the lot up to the tracked products they made.
+func Forward(src Source, productID int, lotNumber, serialNumber string) (*Node, error) {
+	t := tracer{src: src, names: map[int]string{}}
+	return t.root(productID, lotNumber, serialNumber, "OUT", "IN")
+}
+
+type tracer struct {
+	src   Source
+	names map[int]string
+}
+
+// root builds the tree of one lot. `via` is the movement type linking a lot to
+// the orders to follow and `to` the type of the movements found on them.
+func (t *tracer) root(productID int, lotNumber, serialNumber, via, to string) (*Node, error) {
+	name, err := t.name(productID)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{ProductID: productID, ProductName: name, Children: []Node{}}
+	if lotNumber != "" {
+		n.LotNumber = &lotNumber
+	}
+	if serialNumber != "" {
+		n.SerialNumber = &serialNumber
+	}
+
+	children, err := t.expand(productID, lotNumber, serialNumber, via, to, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	n.Children = children
+
+	// the root's quantity is what orders produced of it (backward) or
+	// consumed of it (forward)
+	links, err := t.src.GetTrackedMovements(productID, lotNumber, serialNumber, via)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		n.Quantity += l.Quantity
+	}
+	return n, nil
+}
+
+func (t *tracer) expand(productID int, lotNumber, serialNumber, via, to string, seen map[string]bool) ([]Node, error) {
+	// seen holds the lots on the current path only, so a lot reached twice
+	// through different orders is expanded both times but a loop is not
+	key := lotKey(productID, lotNumber, serialNumber)
+	if seen[key] {
+		return nil, nil
+	}
+	seen[key] = true
+	defer delete(seen, key)
+
+	links, err := t.src.GetTrackedMovements(productID, lotNumber, serialNumber, via)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[int]bool{}
+	children := []Node{}
+	for _, l := range links {
+		if l.ReferenceID == nil || visited[*l.ReferenceID] {
+			continue
+		}
+		moID := *l.ReferenceID
+		visited[moID] = true
+
+		movements, err := t.src.GetMOMovements(moID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range movements {
+			if m.MovementType != to || (m.LotNumber == nil && m.SerialNumber == nil) {
+				continue
+			}
+			name, err := t.name(m.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			id := moID
+			child := Node{
+				ProductID:    m.ProductID,
+				ProductName:  name,
+				LotNumber:    m.LotNumber,
+				SerialNumber: m.SerialNumber,
+				MOID:         &id,
+				Quantity:     m.Quantity,
+			}
+			child.Children, err = t.expand(m.ProductID, deref(m.LotNumber), deref(m.SerialNumber), via, to, seen)
+			if err != nil {
+				return nil, err
+			}
+			if child.Children == nil {
+				child.Children = []Node{}
+			}
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
+
+func (t *tracer) name(productID int) (string, error) {
+	if n, ok := t.names[productID]; ok {
+		return n, nil
+	}
+	p, err := t.src.GetProductById(productID)
+	if err != nil {
+		return "", err
+	}
+	t.names[productID] = p.Name
+	return p.Name, nil
+}
+
+func lotKey(productID int, lotNumber, serialNumber string) string {
+	return fmt.Sprintf("%d|%s|%s", productID, lotNumber, serialNumber)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -3,13 +3,19 @@ package types
 import "time"
 
 type Product struct {
-	ID          int       `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name" validate:"required,min=2,max=100"`
-	Description string    `json:"description,omitempty" db:"description"`
-	Category    string    `json:"category,omitempty" db:"category"`
-	Unit        string    `json:"unit" db:"unit" validate:"required,min=1,max=20"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int    `json:"id" db:"id"`
+	Name        string `json:"name" db:"name" validate:"required,min=2,max=100"`
+	Description string `json:"description,omitempty" db:"description"`
+	Category    string `json:"category,omitempty" db:"category"`
+	Unit        string `json:"unit" db:"unit" validate:"required,min=1,max=20"`
+	// PurchaseCost is the standard cost of one unit when the product is bought in.
+	PurchaseCost float64 `json:"purchase_cost" db:"purchase_cost"`
+	// ConsumptionMode is "backflush" or "manual".
+	ConsumptionMode string `json:"consumption_mode" db:"consumption_mode"`
+	// Tracking is "none", "lot" or "serial".
+	Tracking  string    `json:"tracking" db:"tracking"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type User struct {
@@ -23,34 +29,129 @@ type User struct {
 }
 
 type WorkCenter struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Type      string    `json:"type" db:"type"`
-	Capacity  int       `json:"capacity,omitempty" db:"capacity"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID       int    `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Type     string `json:"type" db:"type"`
+	Capacity int    `json:"capacity,omitempty" db:"capacity"`
+	// Status is available, down or maintenance.
+	Status string `json:"status" db:"status"`
+	// ShiftPatternID is the calendar the center works to; without one it
+	// follows the plant's default pattern.
+	ShiftPatternID *int `json:"shift_pattern_id,omitempty" db:"shift_pattern_id"`
+	// LabourRate and OverheadRate are charged per hour of operation time.
+	LabourRate   float64   `json:"labour_rate" db:"labour_rate"`
+	OverheadRate float64   `json:"overhead_rate" db:"overhead_rate"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type ManufacturingOrder struct {
-	ID                int        `json:"id" db:"id"`
-	ProductID         int        `json:"product_id" db:"product_id"`
-	Quantity          int        `json:"quantity" db:"quantity"`
-	Status            string     `json:"status" db:"status"`
+	ID        int `json:"id" db:"id"`
+	ProductID int `json:"product_id" db:"product_id"`
+	Quantity  int `json:"quantity" db:"quantity"`
+	// ProducedQuantity is what has been reported as produced so far.
+	ProducedQuantity int `json:"produced_quantity" db:"produced_quantity"`
+	// ScrappedQuantity is lost to scrap and no longer expected as good output.
+	ScrappedQuantity int    `json:"scrapped_quantity" db:"scrapped_quantity"`
+	Status           string `json:"status" db:"status"`
+	// Priority runs from 0 (low) to 3 (urgent).
+	Priority          int        `json:"priority" db:"priority"`
 	StartDate         time.Time  `json:"start_date" db:"start_date"`
 	DueDate           *time.Time `json:"due_date,omitempty" db:"due_date"`
 	AssignedManagerID *int       `json:"assigned_manager_id,omitempty" db:"assigned_manager_id"`
-	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+	// OriginMOID is the order this one was split off from or is a backorder of.
+	OriginMOID *int `json:"origin_mo_id,omitempty" db:"origin_mo_id"`
+	// ParentMOID is the order whose sub-assembly shortage this order covers.
+	ParentMOID *int      `json:"parent_mo_id,omitempty" db:"parent_mo_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MOFilter narrows a manufacturing order listing. Zero values do not filter.
+type MOFilter struct {
+	Statuses  []string
+	ProductID int
+	ManagerID int
+	StartFrom *time.Time
+	StartTo   *time.Time
+	DueFrom   *time.Time
+	DueTo     *time.Time
+}
+
+// MOSummary is a manufacturing order as shown on the board.
+type MOSummary struct {
+	ManufacturingOrder
+	// RemainingMinutes is the planned work still to do: the open work orders
+	// once they exist, the routing before that.
+	RemainingMinutes float64 `json:"remaining_minutes"`
+	// RemainingWork is the same work split by operation, in routing order.
+	RemainingWork []WorkStep `json:"remaining_work"`
+	Late          bool       `json:"late"`
+	AtRisk        bool       `json:"at_risk"`
+}
+
+// WorkStep is planned work still to be done at a work center.
+type WorkStep struct {
+	WorkCenterID *int    `json:"work_center_id,omitempty"`
+	Minutes      float64 `json:"minutes"`
+}
+
+// MOTransition records one status change of a manufacturing order.
+type MOTransition struct {
+	ID         int       `json:"id" db:"id"`
+	MOID       int       `json:"mo_id" db:"mo_id"`
+	Action     string    `json:"action" db:"action"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	ActorID    *int      `json:"actor_id,omitempty" db:"actor_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 type BoM struct {
-	ID            int       `json:"id" db:"id"`
-	ProductID     int       `json:"product_id" db:"product_id"`
-	ComponentID   int       `json:"component_id" db:"component_id"`
-	Quantity      float64   `json:"quantity" db:"quantity"`
-	OperationName string    `json:"operation_name,omitempty" db:"operation_name"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID            int     `json:"id" db:"id"`
+	ProductID     int     `json:"product_id" db:"product_id"`
+	ComponentID   int     `json:"component_id" db:"component_id"`
+	Quantity      float64 `json:"quantity" db:"quantity"`
+	OperationName string  `json:"operation_name,omitempty" db:"operation_name"`
+	// OperationID is the routing operation the component is consumed at.
+	OperationID *int `json:"operation_id,omitempty" db:"operation_id"`
+	// WorkCenterID and the minutes define the operation of a product without
+	// a routing; work orders generated from operation_name use them.
+	WorkCenterID      *int      `json:"work_center_id,omitempty" db:"work_center_id"`
+	SetupMinutes      float64   `json:"setup_minutes" db:"setup_minutes"`
+	RunMinutesPerUnit float64   `json:"run_minutes_per_unit" db:"run_minutes_per_unit"`
+	Version           int       `json:"version" db:"version"`
+	ScrapPercent      float64   `json:"scrap_percent" db:"scrap_percent"`
+	Phantom           bool      `json:"phantom" db:"phantom"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BoMAlternate is an approved substitute for the component of a BoM line.
+// ConversionRatio units of the alternate replace one unit of the primary.
+type BoMAlternate struct {
+	ID              int       `json:"id" db:"id"`
+	BoMID           int       `json:"bom_id" db:"bom_id"`
+	ComponentID     int       `json:"component_id" db:"component_id"`
+	Priority        int       `json:"priority" db:"priority"`
+	ConversionRatio float64   `json:"conversion_ratio" db:"conversion_ratio"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RoutingOperation is one step of the routing of a product. Operations run
+// in Sequence order; operations sharing a sequence number may run in parallel.
+type RoutingOperation struct {
+	ID                int       `json:"id" db:"id"`
+	ProductID         int       `json:"product_id" db:"product_id"`
+	Sequence          int       `json:"sequence" db:"sequence"`
+	Name              string    `json:"name" db:"name"`
+	WorkCenterID      int       `json:"work_center_id" db:"work_center_id"`
+	SetupMinutes      float64   `json:"setup_minutes" db:"setup_minutes"`
+	RunMinutesPerUnit float64   `json:"run_minutes_per_unit" db:"run_minutes_per_unit"`
+	Instructions      string    `json:"instructions,omitempty" db:"instructions"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type WorkOrder struct {
@@ -62,9 +163,331 @@ type WorkOrder struct {
 	EndTime          *time.Time `json:"end_time,omitempty" db:"end_time"`
 	AssignedWorkerID *int       `json:"assigned_worker_id,omitempty" db:"assigned_worker_id"`
 	WorkCenterID     *int       `json:"work_center_id,omitempty" db:"work_center_id"`
-	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	Sequence         int        `json:"sequence" db:"sequence"`
+	OperationID      *int       `json:"operation_id,omitempty" db:"operation_id"`
+	PlannedMinutes   float64    `json:"planned_minutes" db:"planned_minutes"`
+	// QueueRank orders the work order within its work center queue, lowest first.
+	QueueRank *float64 `json:"queue_rank,omitempty" db:"queue_rank"`
+	// ActualMinutes is the worked time of the closed sessions, pauses excluded.
+	ActualMinutes float64 `json:"actual_minutes" db:"actual_minutes"`
+	// ReworkOfID is the work order whose rejected units this one reworks.
+	ReworkOfID *int      `json:"rework_of_id,omitempty" db:"rework_of_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WorkOrderDependency says WorkOrderID cannot start before PredecessorID is completed.
+type WorkOrderDependency struct {
+	ID            int       `json:"id" db:"id"`
+	WorkOrderID   int       `json:"work_order_id" db:"work_order_id"`
+	PredecessorID int       `json:"predecessor_id" db:"predecessor_id"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+type ScrapReason struct {
+	ID          int       `json:"id" db:"id"`
+	Code        string    `json:"code" db:"code"`
+	Description string    `json:"description" db:"description"`
+	Active      bool      `json:"active" db:"active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ScrapRecord is one QC rejection reported on a work order. Scrapped units
+// are lost; reworked units go through ReworkWorkOrderID instead.
+type ScrapRecord struct {
+	ID                int       `json:"id" db:"id"`
+	WorkOrderID       int       `json:"work_order_id" db:"work_order_id"`
+	MOID              int       `json:"mo_id" db:"mo_id"`
+	ProductID         int       `json:"product_id" db:"product_id"`
+	WorkCenterID      *int      `json:"work_center_id,omitempty" db:"work_center_id"`
+	ReasonID          int       `json:"reason_id" db:"reason_id"`
+	Quantity          int       `json:"quantity" db:"quantity"`
+	Rework            bool      `json:"rework" db:"rework"`
+	ReworkWorkOrderID *int      `json:"rework_work_order_id,omitempty" db:"rework_work_order_id"`
+	ReportedBy        int       `json:"reported_by" db:"reported_by"`
+	Notes             string    `json:"notes,omitempty" db:"notes"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+// ScrapReportLine totals scrap for one product, work center or reason.
+type ScrapReportLine struct {
+	ID       *int   `json:"id"`
+	Name     string `json:"name"`
+	Records  int    `json:"records"`
+	Scrapped int    `json:"scrapped"`
+	Reworked int    `json:"reworked"`
+}
+
+// WorkOrderTimeLog is one uninterrupted session of work on a work order;
+// EndedAt is nil while it is running.
+type WorkOrderTimeLog struct {
+	ID          int        `json:"id" db:"id"`
+	WorkOrderID int        `json:"work_order_id" db:"work_order_id"`
+	WorkerID    int        `json:"worker_id" db:"worker_id"`
+	StartedAt   time.Time  `json:"started_at" db:"started_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// LabourEntry is one worker's clock-in to clock-out on a work order.
+// Several workers may be clocked in on the same work order at once.
+type LabourEntry struct {
+	ID          int        `json:"id" db:"id"`
+	WorkOrderID int        `json:"work_order_id" db:"work_order_id"`
+	WorkerID    int        `json:"worker_id" db:"worker_id"`
+	ClockIn     time.Time  `json:"clock_in" db:"clock_in"`
+	ClockOut    *time.Time `json:"clock_out,omitempty" db:"clock_out"`
+	Minutes     float64    `json:"minutes" db:"minutes"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
+
+// WorkOrderLabour compares the planned time of a work order with the time
+// it actually ran and the labour booked on it.
+type WorkOrderLabour struct {
+	WorkOrderID    int           `json:"work_order_id"`
+	PlannedMinutes float64       `json:"planned_minutes"`
+	ActualMinutes  float64       `json:"actual_minutes"`
+	LabourMinutes  float64       `json:"labour_minutes"`
+	Variance       float64       `json:"variance_minutes"`
+	Entries        []LabourEntry `json:"entries"`
+}
+
+// TimesheetLine is the labour a worker booked on one work order in one day
+// or week.
+type TimesheetLine struct {
+	Period      time.Time `json:"period"`
+	WorkOrderID int       `json:"work_order_id"`
+	MOID        int       `json:"mo_id"`
+	StepName    string    `json:"step_name"`
+	ProductName string    `json:"product_name"`
+	Entries     int       `json:"entries"`
+	Minutes     float64   `json:"minutes"`
+}
+
+// QueueEntry is a work order waiting at a work center, with the order
+// details the queue is sorted and shown by.
+type QueueEntry struct {
+	WorkOrder
+	ProductID  int        `json:"product_id"`
+	MOPriority int        `json:"mo_priority"`
+	DueDate    *time.Time `json:"due_date,omitempty"`
+	// PlannedStart and PlannedEnd project the work left in queue order onto
+	// the center's shifts from now, around holidays and maintenance.
+	PlannedStart *time.Time `json:"planned_start,omitempty"`
+	PlannedEnd   *time.Time `json:"planned_end,omitempty"`
+}
+
+// WorkerQualification allows a worker to pick up unassigned work at a work center.
+type WorkerQualification struct {
+	ID             int       `json:"id" db:"id"`
+	WorkerID       int       `json:"worker_id" db:"worker_id"`
+	WorkCenterID   int       `json:"work_center_id" db:"work_center_id"`
+	WorkCenterName string    `json:"work_center_name" db:"work_center_name"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// Shift is one working period on a weekday (1 = Monday … 7 = Sunday).
+// Start and End are "HH:MM"; a shift ending at or before its start runs
+// past midnight and counts towards the day it starts on.
+type Shift struct {
+	ID        int    `json:"id" db:"id"`
+	PatternID int    `json:"shift_pattern_id" db:"shift_pattern_id"`
+	Name      string `json:"name" db:"name"`
+	Weekday   int    `json:"weekday" db:"weekday"`
+	Start     string `json:"start" db:"start_time"`
+	End       string `json:"end" db:"end_time"`
+}
+
+// ShiftPattern is a weekly working calendar, e.g. two 8h shifts Mon–Fri.
+type ShiftPattern struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	IsDefault bool      `json:"is_default" db:"is_default"`
+	Shifts    []Shift   `json:"shifts"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Holiday is a non-working day, plant-wide when WorkCenterID is nil.
+type Holiday struct {
+	ID           int       `json:"id" db:"id"`
+	Date         time.Time `json:"date" db:"date"`
+	WorkCenterID *int      `json:"work_center_id,omitempty" db:"work_center_id"`
+	Name         string    `json:"name" db:"name"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// CapacityDay is the working time a work center has on one date.
+type CapacityDay struct {
+	Date   string  `json:"date"`
+	Shifts int     `json:"shifts"`
+	Hours  float64 `json:"hours"`
+	// MaintenanceHours is shift time taken out for planned maintenance.
+	MaintenanceHours float64 `json:"maintenance_hours,omitempty"`
+	Holiday          string  `json:"holiday,omitempty"`
+}
+
+// WorkCenterCapacity is the available time of a work center over a date
+// range. Hours are shift hours, less maintenance, times the center's
+// parallel capacity.
+type WorkCenterCapacity struct {
+	WorkCenterID   int           `json:"work_center_id"`
+	ShiftPatternID *int          `json:"shift_pattern_id,omitempty"`
+	From           string        `json:"from"`
+	To             string        `json:"to"`
+	Units          int           `json:"units"`
+	ShiftHours     float64       `json:"shift_hours"`
+	CapacityHours  float64       `json:"capacity_hours"`
+	Days           []CapacityDay `json:"days"`
+}
+
+// DowntimeEvent is a period a work center stood still. An event without
+// EndedAt is still going on.
+type DowntimeEvent struct {
+	ID           int        `json:"id" db:"id"`
+	WorkCenterID int        `json:"work_center_id" db:"work_center_id"`
+	Category     string     `json:"category" db:"category"`
+	StartedAt    time.Time  `json:"started_at" db:"started_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	Minutes      float64    `json:"minutes" db:"minutes"`
+	Notes        string     `json:"notes,omitempty" db:"notes"`
+	ReportedBy   int        `json:"reported_by" db:"reported_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// WorkOrderRun is a completed work order as OEE sees it: its standard
+// (planned) and actual run time and the units that went through it.
+// Rework work orders carry no units, they were counted the first time.
+type WorkOrderRun struct {
+	WorkOrderID    int       `json:"work_order_id"`
+	EndTime        time.Time `json:"end_time"`
+	PlannedMinutes float64   `json:"planned_minutes"`
+	ActualMinutes  float64   `json:"actual_minutes"`
+	Units          int       `json:"units"`
+}
+
+// OEEPeriod is the overall equipment effectiveness of a work center for one
+// day or shift. Ratios are nil when there is nothing to base them on.
+type OEEPeriod struct {
+	Date            string             `json:"date,omitempty"`
+	Shift           string             `json:"shift,omitempty"`
+	Start           time.Time          `json:"start"`
+	End             time.Time          `json:"end"`
+	PlannedMinutes  float64            `json:"planned_minutes"`
+	DowntimeMinutes float64            `json:"downtime_minutes"`
+	Downtime        map[string]float64 `json:"downtime_by_category"`
+	RunMinutes      float64            `json:"run_minutes"`
+	StandardMinutes float64            `json:"standard_minutes"`
+	Units           int                `json:"units"`
+	RejectedUnits   int                `json:"rejected_units"`
+	Availability    *float64           `json:"availability"`
+	Performance     *float64           `json:"performance"`
+	Quality         *float64           `json:"quality"`
+	OEE             *float64           `json:"oee"`
+}
+
+// OEEReport is a work center's OEE per day or shift plus the whole range.
+type OEEReport struct {
+	WorkCenterID int         `json:"work_center_id"`
+	From         string      `json:"from"`
+	To           string      `json:"to"`
+	GroupBy      string      `json:"group_by"`
+	Periods      []OEEPeriod `json:"periods"`
+	Total        OEEPeriod   `json:"total"`
+}
+
+// MaintenancePlan schedules preventive maintenance on a machine, every
+// IntervalDays (trigger calendar) or every IntervalHours of logged run time
+// (trigger run_hours).
+type MaintenancePlan struct {
+	ID              int        `json:"id" db:"id"`
+	WorkCenterID    int        `json:"work_center_id" db:"work_center_id"`
+	Name            string     `json:"name" db:"name"`
+	Description     string     `json:"description,omitempty" db:"description"`
+	Trigger         string     `json:"trigger" db:"trigger"`
+	IntervalDays    *int       `json:"interval_days,omitempty" db:"interval_days"`
+	IntervalHours   *float64   `json:"interval_hours,omitempty" db:"interval_hours"`
+	DurationMinutes int        `json:"duration_minutes" db:"duration_minutes"`
+	Active          bool       `json:"active" db:"active"`
+	LastDoneAt      *time.Time `json:"last_done_at,omitempty" db:"last_done_at"`
+	// RunHours is the run time logged at the work center since LastDoneAt.
+	RunHours  float64   `json:"run_hours" db:"run_hours"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MaintenanceTask is one maintenance job generated from a plan. It blocks
+// its work center from DueAt to PlannedEnd while planned, and for as long
+// as it runs.
+type MaintenanceTask struct {
+	ID           int        `json:"id" db:"id"`
+	PlanID       *int       `json:"plan_id,omitempty" db:"plan_id"`
+	WorkCenterID int        `json:"work_center_id" db:"work_center_id"`
+	Title        string     `json:"title" db:"title"`
+	Status       string     `json:"status" db:"status"`
+	DueAt        time.Time  `json:"due_at" db:"due_at"`
+	PlannedEnd   time.Time  `json:"planned_end" db:"planned_end"`
+	StartedAt    *time.Time `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CompletedBy  *int       `json:"completed_by,omitempty" db:"completed_by"`
+	RunHours     float64    `json:"run_hours" db:"run_hours"`
+	Notes        string     `json:"notes,omitempty" db:"notes"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Skill is something a worker can be certified for, such as welding.
+type Skill struct {
+	ID          int       `json:"id" db:"id"`
+	Code        string    `json:"code" db:"code"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UserSkill is a worker's certification for a skill. A certification
+// without an expiry date never lapses.
+type UserSkill struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	SkillID     int        `json:"skill_id" db:"skill_id"`
+	SkillCode   string     `json:"skill_code" db:"skill_code"`
+	SkillName   string     `json:"skill_name" db:"skill_name"`
+	CertifiedAt *time.Time `json:"certified_at,omitempty" db:"certified_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	Reference   string     `json:"reference,omitempty" db:"reference"`
+	Expired     bool       `json:"expired" db:"expired"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ExpiringCertification is a certification that lapses soon, with who holds it.
+type ExpiringCertification struct {
+	UserSkill
+	UserName string `json:"user_name"`
+	DaysLeft int    `json:"days_left"`
+}
+
+// TaskComponent is a component a work order consumes, for the whole order quantity.
+type TaskComponent struct {
+	ComponentID   int     `json:"component_id"`
+	ComponentName string  `json:"component_name"`
+	Quantity      float64 `json:"quantity"`
+}
+
+// Task is a work order as shown in a worker's to-do list.
+type Task struct {
+	QueueEntry
+	ProductName  string          `json:"product_name"`
+	Instructions string          `json:"instructions,omitempty"`
+	Components   []TaskComponent `json:"components"`
+}
+
 type Inventory struct {
 	ID             int       `json:"id" db:"id"`
 	ProductID      int       `json:"product_id" db:"product_id"`
@@ -74,6 +497,61 @@ type Inventory struct {
 	ReferenceType  *string   `json:"reference_type,omitempty" db:"reference_type"`
 	ReferenceID    *int      `json:"reference_id,omitempty" db:"reference_id"`
 	CurrentBalance float64   `json:"current_balance" db:"current_balance"`
+	LotNumber      *string   `json:"lot_number,omitempty" db:"lot_number"`
+	SerialNumber   *string   `json:"serial_number,omitempty" db:"serial_number"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// ProductionOutput identifies the finished units of one production report:
+// a lot number for lot-tracked products, one serial per unit for serialised ones.
+type ProductionOutput struct {
+	LotNumber     string   `json:"lot_number,omitempty"`
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+}
+
+// LotBalance is the stock held under one lot or serial number.
+type LotBalance struct {
+	ProductID    int     `json:"product_id"`
+	LotNumber    *string `json:"lot_number,omitempty"`
+	SerialNumber *string `json:"serial_number,omitempty"`
+	Quantity     float64 `json:"quantity"`
+}
+
+// OperationCost is an operation's standard times together with the hourly
+// rates of the work center it runs at.
+type OperationCost struct {
+	OperationID       int     `json:"operation_id"`
+	Name              string  `json:"name"`
+	WorkCenterID      int     `json:"work_center_id"`
+	SetupMinutes      float64 `json:"setup_minutes"`
+	RunMinutesPerUnit float64 `json:"run_minutes_per_unit"`
+	LabourRate        float64 `json:"labour_rate"`
+	OverheadRate      float64 `json:"overhead_rate"`
+}
+
+type ProductCost struct {
+	ID           int       `json:"id" db:"id"`
+	ProductID    int       `json:"product_id" db:"product_id"`
+	LotSize      float64   `json:"lot_size" db:"lot_size"`
+	MaterialCost float64   `json:"material_cost" db:"material_cost"`
+	LabourCost   float64   `json:"labour_cost" db:"labour_cost"`
+	OverheadCost float64   `json:"overhead_cost" db:"overhead_cost"`
+	TotalCost    float64   `json:"total_cost" db:"total_cost"`
+	RolledAt     time.Time `json:"rolled_at" db:"rolled_at"`
+}
+
+// MaterialReservation earmarks stock of a component, or of one of its
+// substitutes, for a manufacturing order.
+type MaterialReservation struct {
+	ID          int       `json:"id" db:"id"`
+	MOID        int       `json:"mo_id" db:"mo_id"`
+	BoMID       int       `json:"bom_id" db:"bom_id"`
+	ComponentID int       `json:"component_id" db:"component_id"`
+	Quantity    float64   `json:"quantity" db:"quantity"`
+	Substitute  bool      `json:"substitute" db:"substitute"`
+	AlternateID *int      `json:"alternate_id,omitempty" db:"alternate_id"`
+	Status      string    `json:"status" db:"status"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -2,11 +2,14 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mma_api/internal/apperr"
 	"net/http"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
 )
 
 type Response struct {
@@ -46,3 +49,23 @@ func ValidateError(errs validator.ValidationErrors) Response {
 		Error:  strings.Join(errMsgs, ","),
 	}
 }
+
+// ErrorStatus maps an error to the HTTP status handlers answer with: 404 for
+// missing records, 409 for conflicts with the current state, 403 for work the
+// caller may not do and 422 for input that can never be accepted.
+func ErrorStatus(err error) int {
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, apperr.NotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperr.Conflict):
+		return http.StatusConflict
+	case errors.Is(err, apperr.Forbidden):
+		return http.StatusForbidden
+	case errors.Is(err, apperr.Invalid):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}